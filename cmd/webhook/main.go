@@ -2,25 +2,50 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	_ "time/tzdata" // Maintenance time zones without tzdata in the image
 
+	"github.com/894/node-cleanup-webhook/pkg/apis/generated/clientset/versioned"
+	"github.com/894/node-cleanup-webhook/pkg/approval"
+	"github.com/894/node-cleanup-webhook/pkg/audit"
+	"github.com/894/node-cleanup-webhook/pkg/certs"
 	"github.com/894/node-cleanup-webhook/pkg/config"
 	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/election"
+	"github.com/894/node-cleanup-webhook/pkg/health"
+	"github.com/894/node-cleanup-webhook/pkg/maintenance"
 	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	"github.com/894/node-cleanup-webhook/pkg/scope"
 	"github.com/894/node-cleanup-webhook/pkg/watcher"
 	"github.com/894/node-cleanup-webhook/pkg/webhook"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "uninstall" {
+		os.Exit(runUninstall(os.Args[2:]))
+	}
+
 	klog.InitFlags(nil)
 
 	// Parse command-line flags
@@ -28,10 +53,12 @@ func main() {
 	var tlsCert string
 	var tlsKey string
 	var port int
+	var metricsPort int
 
 	flag.StringVar(&tlsCert, "tls-cert", "", "TLS certificate file (overrides env)")
 	flag.StringVar(&tlsKey, "tls-key", "", "TLS key file (overrides env)")
 	flag.IntVar(&port, "port", 0, "Webhook server port (overrides env)")
+	flag.IntVar(&metricsPort, "metrics-port", -1, "Plain HTTP metrics port, 0 disables (overrides env)")
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig (uses in-cluster config if empty)")
 	flag.Parse()
 
@@ -51,6 +78,9 @@ func main() {
 	if kubeconfig != "" {
 		cfg.Kubeconfig = kubeconfig
 	}
+	if metricsPort >= 0 {
+		cfg.MetricsPort = metricsPort
+	}
 
 	// Print configuration
 	klog.Info("===========================================")
@@ -59,28 +89,96 @@ func main() {
 	cfg.Print()
 	klog.Info("===========================================")
 
-	// Create Kubernetes client
-	client, err := createK8sClient(cfg.Kubeconfig, cfg.InsecureSkipTLSVerify)
+	// Create Kubernetes clients
+	restConfig, err := createRestConfig(cfg.Kubeconfig, cfg.InsecureSkipTLSVerify)
+	if err != nil {
+		klog.Fatalf("Failed to create Kubernetes client config: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		klog.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
+	cleanupClient, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		klog.Fatalf("Failed to create NodeCleanup client: %v", err)
+	}
+
+	// Nodes that get the cleanup finalizer, shared by webhook and watcher
+	nodeScope, err := scope.New(cfg.NodeSelector, cfg.NodeExcludeTaints, cfg.NodeExcludeAnnotations)
+	if err != nil {
+		klog.Fatalf("Invalid node scope: %v", err)
+	}
+
+	// Record cleanup lifecycle events against nodes
+	broadcaster, recorder := createEventRecorder(client)
+	defer broadcaster.Shutdown()
 
 	// Initialize plugin registry
 	pluginRegistry := plugins.NewRegistry()
+	pluginRegistry.SetEventRecorder(recorder)
+	pluginRegistry.SetDefaultTimeout(cfg.PluginTimeout)
+
+	// The audit log relies on stdout carrying nothing but its records
+	if cfg.AuditLog == audit.Stdout && cfg.PluginConfigs["logger"].Enabled &&
+		cfg.GetPluginOption("logger", "output", "stdout") == "stdout" {
+		klog.Fatal("AUDIT_LOG=stdout and LOGGER_OUTPUT=stdout would interleave: set LOGGER_OUTPUT=stderr")
+	}
+
+	// Node cache for plugins that look at other nodes when validating a
+	// deletion; unlike the watcher's informer it runs on every replica
+	nodeInformers := informers.NewSharedInformerFactory(client, constants.DefaultInformerResyncPeriod)
 
 	// Register available plugins
 	klog.Info("Registering cleanup plugins...")
-	pluginRegistry.Register(plugins.NewLoggerPlugin(client))
-	pluginRegistry.Register(plugins.NewPortworxPlugin(client, cfg.GetPluginOption("portworx", "labelSelector", constants.DefaultPortworxLabelSelector)))
+	pluginRegistry.Register(plugins.NewLoggerPlugin(client,
+		cfg.GetPluginOption("logger", "format", constants.DefaultLoggerFormat),
+		cfg.GetPluginOption("logger", "verbosity", constants.DefaultLoggerVerbosity),
+		cfg.GetPluginOptionDuration("logger", "delay", 0),
+		loggerOutput(cfg.GetPluginOption("logger", "output", "stdout"))))
+	pluginRegistry.Register(plugins.NewPortworxPlugin(client, nodeInformers.Core().V1().Nodes().Lister(),
+		cfg.GetPluginOption("portworx", "labelSelector", constants.DefaultPortworxLabelSelector),
+		cfg.GetPluginOptionInt("portworx", "minNodes", constants.DefaultPortworxMinNodes)))
 
 	// Enable configured plugins
 	klog.Info("Enabling plugins based on configuration...")
 	for _, pluginName := range cfg.EnabledPlugins {
 		if err := pluginRegistry.Enable(pluginName); err != nil {
 			klog.Warningf("Failed to enable plugin %s: %v", pluginName, err)
+			continue
 		}
+		pluginRegistry.SetTimeout(pluginName, cfg.GetPluginTimeout(pluginName))
+		pluginRegistry.SetRequiresApproval(pluginName, cfg.GetPluginRequiresApproval(pluginName))
+		if after, ok := cfg.GetPluginAfter(pluginName); ok {
+			pluginRegistry.SetAfter(pluginName, after)
+		}
+	}
+
+	// Resolve plugin dependencies; a cycle can never complete a cleanup
+	if err := pluginRegistry.BuildGraph(); err != nil {
+		klog.Fatalf("Invalid plugin configuration: %v", err)
 	}
 
+	// Nodes whose cleanup waits for manual approval
+	approvalPolicy, err := approval.New(cfg.ApprovalNodeSelector, cfg.ApprovalTimeout, cfg.ApprovalTimeoutAction, pluginRegistry)
+	if err != nil {
+		klog.Fatalf("Invalid approval configuration: %v", err)
+	}
+
+	// When destructive cleanup may start
+	schedule, err := maintenance.New(cfg.MaintenanceWindows, cfg.MaintenanceTimezone, cfg.MaintenanceBlackoutDates)
+	if err != nil {
+		klog.Fatalf("Invalid maintenance schedule: %v", err)
+	}
+	klog.Infof("🕑 Maintenance schedule: %s", schedule)
+
+	// Append-only record of every cleanup decision
+	auditLog, err := audit.New(cfg.AuditLog, int64(cfg.AuditLogMaxSizeMB)*1024*1024, cfg.AuditLogMaxBackups)
+	if err != nil {
+		klog.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer auditLog.Close()
+	klog.Infof("📜 Audit log: %s", auditLog)
+
 	// Show enabled plugins
 	enabledPlugins := pluginRegistry.GetEnabledPlugins()
 	if len(enabledPlugins) == 0 {
@@ -92,33 +190,110 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Only the Portworx quorum check needs the node cache
+	if cfg.PluginConfigs["portworx"].Enabled {
+		nodeInformers.Start(ctx.Done())
+		for informer, synced := range nodeInformers.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				klog.Fatalf("Failed to sync %v informer cache", informer)
+			}
+		}
+	}
+
+	// Approvals are only checked against the approving user by the node
+	// UPDATE webhook; without it approval gating would be a formality
+	if approvalPolicy.Enabled() {
+		if err := approval.CheckEnforced(ctx, client, cfg.WebhookConfigName, constants.ValidateNodePath); err != nil {
+			klog.Fatalf("Manual approval is configured but approvals are not enforced (enable finalizer protection): %v", err)
+		}
+	}
+
 	// Handle shutdown gracefully
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start cleanup watcher with plugin registry
-	nodeWatcher := watcher.New(ctx, client, pluginRegistry)
-	go nodeWatcher.Run()
+	// Start cleanup watcher with plugin registry. With leader election only
+	// the leader runs it; the webhook below is served by every replica.
+	// The running watcher, if any, is published for the readiness checks
+	var activeWatcher atomic.Pointer[watcher.Watcher]
+	runWatcher := func(ctx context.Context) {
+		w := watcher.New(ctx, client, cleanupClient, pluginRegistry, recorder, nodeScope, approvalPolicy, schedule, auditLog, cfg)
+		activeWatcher.Store(w)
+		defer activeWatcher.Store(nil)
+		w.Run()
+	}
+	watcherDone := make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		if !cfg.LeaderElect {
+			runWatcher(ctx)
+			return
+		}
+		if err := election.Run(ctx, client, cfg, runWatcher); err != nil {
+			klog.Fatalf("Leader election failed: %v", err)
+		}
+	}()
+
+	// Without cert-manager, issue the certificate ourselves before serving it
+	if cfg.SelfManagedCerts {
+		certManager := certs.NewManager(client, cfg)
+		if err := certManager.Sync(ctx); err != nil {
+			klog.Fatalf("Failed to set up self-managed certificates: %v", err)
+		}
+		go certManager.Run(ctx, constants.SelfManagedCertSyncInterval)
+	}
+
+	// Serve the certificate through a reloader so rotations apply without a restart
+	certReloader, err := certs.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile, constants.CertificateReloadInterval)
+	if err != nil {
+		klog.Fatalf("Failed to load webhook certificate: %v", err)
+	}
+	go certReloader.Run(ctx)
 
 	// Start webhook server
-	webhookServer := webhook.NewServer()
+	webhookServer := webhook.NewServer(pluginRegistry, nodeScope, cfg.ServiceAccountUsername(), auditLog, cfg.DryRun)
+	mux := http.NewServeMux()
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      mux,
 		ReadTimeout:  constants.DefaultHTTPReadTimeout,
 		WriteTimeout: constants.DefaultHTTPWriteTimeout,
+		TLSConfig:    &tls.Config{GetCertificate: certReloader.GetCertificate},
 	}
 
-	http.HandleFunc("/mutate-node", webhookServer.HandleMutateNode)
-	http.HandleFunc("/healthz", handleHealthz)
-	http.HandleFunc("/readyz", handleReadyz)
+	router := webhook.NewRouter(mux, constants.MaxAdmissionReviewBytes)
+	router.Handle("/mutate-node", webhookServer.MutateNode, admissionregistrationv1.FailurePolicyType(cfg.MutateFailurePolicy))
+	router.Handle(constants.ValidateNodePath, webhookServer.ValidateNode, admissionregistrationv1.FailurePolicyType(cfg.ValidateFailurePolicy))
+	mux.Handle(constants.ApprovalPath, approval.NewHandler(client))
+	mux.Handle(constants.BreakerResetPath, watcher.NewBreakerResetHandler(client, cfg.Namespace, cfg.BreakerConfigMapName))
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.Handle("/readyz", readinessChecker(cfg, pluginRegistry, &activeWatcher, certReloader))
 
 	go func() {
 		klog.Infof("🚀 Starting webhook server on port %d", cfg.Port)
-		if err := server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil && err != http.ErrServerClosed {
+		if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
 			klog.Fatalf("Webhook server failed: %v", err)
 		}
 	}()
 
+	// Serve metrics over plain HTTP so Prometheus does not need the webhook CA
+	var metricsServer *http.Server
+	if cfg.MetricsPort != 0 {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", promhttp.Handler())
+		metricsServer = &http.Server{
+			Addr:        fmt.Sprintf(":%d", cfg.MetricsPort),
+			Handler:     metricsMux,
+			ReadTimeout: constants.DefaultHTTPReadTimeout,
+		}
+		go func() {
+			klog.Infof("📊 Starting metrics server on port %d", cfg.MetricsPort)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				klog.Fatalf("Metrics server failed: %v", err)
+			}
+		}()
+	}
+
 	// Wait for shutdown signal
 	<-sigCh
 	klog.Info("⏹️  Shutting down...")
@@ -126,15 +301,41 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), constants.DefaultShutdownTimeout)
 	defer shutdownCancel()
 
+	// Stop the watcher first and wait for it to release the lease so another
+	// replica can take over while this one finishes serving admissions
+	cancel()
+	select {
+	case <-watcherDone:
+	case <-shutdownCtx.Done():
+		klog.Warning("Timed out waiting for cleanup watcher to stop")
+	}
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		klog.Errorf("Webhook server shutdown error: %v", err)
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("Metrics server shutdown error: %v", err)
+		}
+	}
 
-	cancel() // Stop the watcher
 	klog.Info("✅ Shutdown complete")
 }
 
-func createK8sClient(kubeconfig string, insecureSkipTLSVerify bool) (kubernetes.Interface, error) {
+// loggerOutput returns the logger plugin's writer: "stdout" or "stderr"
+func loggerOutput(name string) *os.File {
+	switch name {
+	case "stdout":
+		return os.Stdout
+	case "stderr":
+		return os.Stderr
+	default:
+		klog.Warningf("Invalid logger output %q, using stdout", name)
+		return os.Stdout
+	}
+}
+
+func createRestConfig(kubeconfig string, insecureSkipTLSVerify bool) (*rest.Config, error) {
 	var restConfig *rest.Config
 	var err error
 
@@ -155,7 +356,16 @@ func createK8sClient(kubeconfig string, insecureSkipTLSVerify bool) (kubernetes.
 		restConfig.TLSClientConfig.CAFile = ""
 	}
 
-	return kubernetes.NewForConfig(restConfig)
+	return restConfig, nil
+}
+
+// createEventRecorder starts an event broadcaster that writes events to the API server
+func createEventRecorder(client kubernetes.Interface) (record.EventBroadcaster, record.EventRecorder) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartStructuredLogging(4)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: constants.EventComponent})
+	return broadcaster, recorder
 }
 
 func handleHealthz(w http.ResponseWriter, r *http.Request) {
@@ -163,7 +373,54 @@ func handleHealthz(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("ok"))
 }
 
-func handleReadyz(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
+// readinessChecker builds the /readyz checks: the watcher's informer cache
+// (when this replica runs the watcher), the serving certificate and the
+// health of plugins that implement plugins.HealthChecker
+func readinessChecker(cfg *config.Config, pluginRegistry *plugins.Registry, activeWatcher *atomic.Pointer[watcher.Watcher], certReloader *certs.Reloader) *health.Checker {
+	checker := health.NewChecker(constants.ReadinessCheckTimeout)
+
+	checker.Add("informer-sync", func(ctx context.Context) (string, error) {
+		w := activeWatcher.Load()
+		if w == nil {
+			if cfg.LeaderElect {
+				// Standby replicas only serve admissions
+				return "standby, not leader", nil
+			}
+			return "", fmt.Errorf("cleanup watcher not running")
+		}
+		if !w.HasSynced() {
+			return "", fmt.Errorf("node informer cache not synced")
+		}
+		if cfg.LeaderElect {
+			return "leader", nil
+		}
+		return "", nil
+	})
+
+	checker.Add("tls-certificate", health.CertificateCheck(certReloader.Certificate))
+
+	checker.Add("plugins", func(ctx context.Context) (string, error) {
+		results := pluginRegistry.CheckHealth(ctx)
+		names := make([]string, 0, len(results))
+		for name := range results {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var failed []string
+		for _, name := range names {
+			if err := results[name]; err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+		if len(failed) > 0 {
+			return "", fmt.Errorf("%s", strings.Join(failed, "; "))
+		}
+		if len(names) == 0 {
+			return "no plugin health checks", nil
+		}
+		return fmt.Sprintf("checked %s", strings.Join(names, ", ")), nil
+	})
+
+	return checker
 }
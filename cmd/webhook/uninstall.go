@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/uninstall"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// runUninstall implements the "uninstall" subcommand and returns the exit code
+func runUninstall(args []string) int {
+	flags := flag.NewFlagSet("uninstall", flag.ExitOnError)
+	klog.InitFlags(flags)
+
+	var opts uninstall.Options
+	var kubeconfig string
+	var insecureSkipTLSVerify bool
+
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig (uses in-cluster config if empty)")
+	flags.BoolVar(&insecureSkipTLSVerify, "insecure-skip-tls-verify", false, "Skip TLS verification for kube-apiserver")
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "Report what would change without changing anything")
+	flags.BoolVar(&opts.DeleteWebhookConfigs, "delete-webhook-config", true,
+		"Delete the Mutating/ValidatingWebhookConfiguration first so new nodes are not given the finalizer again")
+	flags.StringVar(&opts.WebhookConfigName, "webhook-config-name", constants.DefaultWebhookConfigName,
+		"Name of the webhook configurations (the Helm release fullname)")
+	flags.StringVar(&opts.RemovalReason, "removal-reason", uninstall.DefaultRemovalReason,
+		fmt.Sprintf("With --delete-webhook-config=false, the %s annotation set while removing the finalizer",
+			constants.AllowFinalizerRemovalAnnotation))
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s uninstall [flags]\n\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Removes the %s finalizer from every node.\n", constants.FinalizerName)
+		fmt.Fprintf(flags.Output(), "Stop the webhook Deployment first, otherwise its watcher adds the finalizer again.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	restConfig, err := createRestConfig(kubeconfig, insecureSkipTLSVerify)
+	if err != nil {
+		klog.Errorf("Failed to create Kubernetes client config: %v", err)
+		return 1
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		klog.Errorf("Failed to create Kubernetes client: %v", err)
+		return 1
+	}
+
+	if !opts.DeleteWebhookConfigs {
+		klog.Warningf("Keeping the webhook configurations: nodes are annotated %s=%q so finalizer protection allows the removal; "+
+			"if the webhook is unreachable and its failurePolicy is Fail, the removal is still denied",
+			constants.AllowFinalizerRemovalAnnotation, opts.RemovalReason)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := uninstall.Run(ctx, client, opts)
+	printUninstallResult(result, opts.DryRun)
+	if err != nil {
+		klog.Errorf("Uninstall failed: %v", err)
+		return 1
+	}
+	if len(result.Failed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printUninstallResult reports what the uninstall changed
+func printUninstallResult(result *uninstall.Result, dryRun bool) {
+	prefix := ""
+	if dryRun {
+		prefix = "(dry run) would have "
+	}
+
+	for _, config := range result.DeletedWebhookConfigs {
+		fmt.Printf("%sdeleted %s\n", prefix, config)
+	}
+
+	terminating := make(map[string]bool, len(result.ReleasedTerminatingNodes))
+	for _, name := range result.ReleasedTerminatingNodes {
+		terminating[name] = true
+	}
+	for _, name := range result.ReleasedNodes {
+		if terminating[name] {
+			fmt.Printf("%sremoved finalizer from node/%s (terminating, deletion proceeds without cleanup)\n", prefix, name)
+		} else {
+			fmt.Printf("%sremoved finalizer from node/%s\n", prefix, name)
+		}
+	}
+
+	failed := make([]string, 0, len(result.Failed))
+	for name := range result.Failed {
+		failed = append(failed, name)
+	}
+	sort.Strings(failed)
+	for _, name := range failed {
+		fmt.Printf("failed to remove finalizer from node/%s: %v\n", name, result.Failed[name])
+	}
+
+	fmt.Printf("%d webhook configuration(s), %d node(s) released (%d terminating), %d failed\n",
+		len(result.DeletedWebhookConfigs), len(result.ReleasedNodes), len(result.ReleasedTerminatingNodes), len(failed))
+}
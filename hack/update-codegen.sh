@@ -0,0 +1,69 @@
+#!/usr/bin/env bash
+# Regenerates deepcopy functions, the typed clientset, listers and informers
+# for the API types under pkg/apis. Requires the k8s.io/code-generator
+# binaries matching the client-go version in go.mod.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+MODULE=github.com/894/node-cleanup-webhook
+APIS_PKG=${MODULE}/pkg/apis
+OUTPUT_PKG=${APIS_PKG}/generated
+GROUP_VERSIONS=infra/v1alpha1
+CODEGEN_VERSION=v0.29.0
+
+REPO_ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+BOILERPLATE=${REPO_ROOT}/hack/boilerplate.go.txt
+
+GOBIN=${GOBIN:-$(go env GOPATH)/bin}
+for gen in deepcopy-gen client-gen lister-gen informer-gen; do
+	if [ ! -x "${GOBIN}/${gen}" ]; then
+		GOFLAGS= go install "k8s.io/code-generator/cmd/${gen}@${CODEGEN_VERSION}"
+	fi
+done
+
+# The generators write into <output-base>/<import path>
+OUTPUT_BASE=$(mktemp -d)
+trap 'rm -rf "${OUTPUT_BASE}"' EXIT
+
+INPUT_DIRS=""
+for gv in ${GROUP_VERSIONS}; do
+	INPUT_DIRS="${INPUT_DIRS:+${INPUT_DIRS},}${APIS_PKG}/${gv}"
+done
+
+cd "${REPO_ROOT}"
+
+"${GOBIN}/deepcopy-gen" \
+	--input-dirs "${INPUT_DIRS}" \
+	--output-file-base zz_generated.deepcopy \
+	--output-base "${OUTPUT_BASE}" \
+	--go-header-file "${BOILERPLATE}"
+
+"${GOBIN}/client-gen" \
+	--clientset-name versioned \
+	--input-base "" \
+	--input "${INPUT_DIRS}" \
+	--output-package "${OUTPUT_PKG}/clientset" \
+	--output-base "${OUTPUT_BASE}" \
+	--go-header-file "${BOILERPLATE}"
+
+"${GOBIN}/lister-gen" \
+	--input-dirs "${INPUT_DIRS}" \
+	--output-package "${OUTPUT_PKG}/listers" \
+	--output-base "${OUTPUT_BASE}" \
+	--go-header-file "${BOILERPLATE}"
+
+"${GOBIN}/informer-gen" \
+	--input-dirs "${INPUT_DIRS}" \
+	--versioned-clientset-package "${OUTPUT_PKG}/clientset/versioned" \
+	--listers-package "${OUTPUT_PKG}/listers" \
+	--output-package "${OUTPUT_PKG}/informers" \
+	--output-base "${OUTPUT_BASE}" \
+	--go-header-file "${BOILERPLATE}"
+
+for gv in ${GROUP_VERSIONS}; do
+	cp "${OUTPUT_BASE}/${APIS_PKG}/${gv}/zz_generated.deepcopy.go" "pkg/apis/${gv}/"
+done
+rm -rf pkg/apis/generated
+cp -r "${OUTPUT_BASE}/${OUTPUT_PKG}" pkg/apis/generated
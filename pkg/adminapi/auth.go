@@ -0,0 +1,76 @@
+// Package adminapi authenticates and authorizes callers of the webhook's
+// admin endpoints, such as cleanup approval and circuit breaker reset.
+package adminapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// Authorize identifies the caller by the Kubernetes bearer token of the
+// request and checks with a SubjectAccessReview that they may perform
+// attributes, like a request to the API server would be. On failure the
+// error response has been written and ok is false.
+func Authorize(w http.ResponseWriter, r *http.Request, client kubernetes.Interface, attributes authorizationv1.ResourceAttributes) (username string, ok bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "admin requests must be POSTed", http.StatusMethodNotAllowed)
+		return "", false
+	}
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return "", false
+	}
+
+	ctx := r.Context()
+
+	// Who is asking
+	review, err := client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Failed to review admin API token", "path", r.URL.Path)
+		http.Error(w, "failed to authenticate", http.StatusInternalServerError)
+		return "", false
+	}
+	if !review.Status.Authenticated {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return "", false
+	}
+	user := review.Status.User
+
+	// May they do it
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	access, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+			ResourceAttributes: &attributes,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Failed to authorize admin API request", "path", r.URL.Path, "user", user.Username)
+		http.Error(w, "failed to authorize", http.StatusInternalServerError)
+		return "", false
+	}
+	if !access.Status.Allowed {
+		klog.InfoS("Denying admin API request", "path", r.URL.Path, "user", user.Username, "reason", access.Status.Reason)
+		http.Error(w, fmt.Sprintf("user %q may not %s %s/%s in group %s", user.Username,
+			attributes.Verb, attributes.Resource, attributes.Subresource, attributes.Group), http.StatusForbidden)
+		return "", false
+	}
+
+	return user.Username, true
+}
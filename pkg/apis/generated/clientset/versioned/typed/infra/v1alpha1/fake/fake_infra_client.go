@@ -0,0 +1,24 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/generated/clientset/versioned/typed/infra/v1alpha1"
+	rest "k8s.io/client-go/rest"
+	testing "k8s.io/client-go/testing"
+)
+
+type FakeInfraV1alpha1 struct {
+	*testing.Fake
+}
+
+func (c *FakeInfraV1alpha1) NodeCleanups() v1alpha1.NodeCleanupInterface {
+	return &FakeNodeCleanups{c}
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeInfraV1alpha1) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
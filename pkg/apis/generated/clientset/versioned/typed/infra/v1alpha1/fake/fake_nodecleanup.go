@@ -0,0 +1,116 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/infra/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeNodeCleanups implements NodeCleanupInterface
+type FakeNodeCleanups struct {
+	Fake *FakeInfraV1alpha1
+}
+
+var nodecleanupsResource = v1alpha1.SchemeGroupVersion.WithResource("nodecleanups")
+
+var nodecleanupsKind = v1alpha1.SchemeGroupVersion.WithKind("NodeCleanup")
+
+// Get takes name of the nodeCleanup, and returns the corresponding nodeCleanup object, and an error if there is any.
+func (c *FakeNodeCleanups) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.NodeCleanup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(nodecleanupsResource, name), &v1alpha1.NodeCleanup{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.NodeCleanup), err
+}
+
+// List takes label and field selectors, and returns the list of NodeCleanups that match those selectors.
+func (c *FakeNodeCleanups) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.NodeCleanupList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(nodecleanupsResource, nodecleanupsKind, opts), &v1alpha1.NodeCleanupList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.NodeCleanupList{ListMeta: obj.(*v1alpha1.NodeCleanupList).ListMeta}
+	for _, item := range obj.(*v1alpha1.NodeCleanupList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested nodeCleanups.
+func (c *FakeNodeCleanups) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(nodecleanupsResource, opts))
+}
+
+// Create takes the representation of a nodeCleanup and creates it.  Returns the server's representation of the nodeCleanup, and an error, if there is any.
+func (c *FakeNodeCleanups) Create(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.CreateOptions) (result *v1alpha1.NodeCleanup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(nodecleanupsResource, nodeCleanup), &v1alpha1.NodeCleanup{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.NodeCleanup), err
+}
+
+// Update takes the representation of a nodeCleanup and updates it. Returns the server's representation of the nodeCleanup, and an error, if there is any.
+func (c *FakeNodeCleanups) Update(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.UpdateOptions) (result *v1alpha1.NodeCleanup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(nodecleanupsResource, nodeCleanup), &v1alpha1.NodeCleanup{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.NodeCleanup), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeNodeCleanups) UpdateStatus(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.UpdateOptions) (*v1alpha1.NodeCleanup, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(nodecleanupsResource, "status", nodeCleanup), &v1alpha1.NodeCleanup{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.NodeCleanup), err
+}
+
+// Delete takes name of the nodeCleanup and deletes it. Returns an error if one occurs.
+func (c *FakeNodeCleanups) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteActionWithOptions(nodecleanupsResource, name, opts), &v1alpha1.NodeCleanup{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeNodeCleanups) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(nodecleanupsResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.NodeCleanupList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched nodeCleanup.
+func (c *FakeNodeCleanups) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.NodeCleanup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(nodecleanupsResource, name, pt, data, subresources...), &v1alpha1.NodeCleanup{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.NodeCleanup), err
+}
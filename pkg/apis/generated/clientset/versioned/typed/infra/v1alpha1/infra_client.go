@@ -0,0 +1,91 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/894/node-cleanup-webhook/pkg/apis/generated/clientset/versioned/scheme"
+	v1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/infra/v1alpha1"
+	rest "k8s.io/client-go/rest"
+)
+
+type InfraV1alpha1Interface interface {
+	RESTClient() rest.Interface
+	NodeCleanupsGetter
+}
+
+// InfraV1alpha1Client is used to interact with features provided by the infra.894.io group.
+type InfraV1alpha1Client struct {
+	restClient rest.Interface
+}
+
+func (c *InfraV1alpha1Client) NodeCleanups() NodeCleanupInterface {
+	return newNodeCleanups(c)
+}
+
+// NewForConfig creates a new InfraV1alpha1Client for the given config.
+// NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
+// where httpClient was generated with rest.HTTPClientFor(c).
+func NewForConfig(c *rest.Config) (*InfraV1alpha1Client, error) {
+	config := *c
+	if err := setConfigDefaults(&config); err != nil {
+		return nil, err
+	}
+	httpClient, err := rest.HTTPClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return NewForConfigAndClient(&config, httpClient)
+}
+
+// NewForConfigAndClient creates a new InfraV1alpha1Client for the given config and http client.
+// Note the http client provided takes precedence over the configured transport values.
+func NewForConfigAndClient(c *rest.Config, h *http.Client) (*InfraV1alpha1Client, error) {
+	config := *c
+	if err := setConfigDefaults(&config); err != nil {
+		return nil, err
+	}
+	client, err := rest.RESTClientForConfigAndClient(&config, h)
+	if err != nil {
+		return nil, err
+	}
+	return &InfraV1alpha1Client{client}, nil
+}
+
+// NewForConfigOrDie creates a new InfraV1alpha1Client for the given config and
+// panics if there is an error in the config.
+func NewForConfigOrDie(c *rest.Config) *InfraV1alpha1Client {
+	client, err := NewForConfig(c)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// New creates a new InfraV1alpha1Client for the given RESTClient.
+func New(c rest.Interface) *InfraV1alpha1Client {
+	return &InfraV1alpha1Client{c}
+}
+
+func setConfigDefaults(config *rest.Config) error {
+	gv := v1alpha1.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	return nil
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *InfraV1alpha1Client) RESTClient() rest.Interface {
+	if c == nil {
+		return nil
+	}
+	return c.restClient
+}
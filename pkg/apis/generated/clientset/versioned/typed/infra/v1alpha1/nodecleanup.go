@@ -0,0 +1,168 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	scheme "github.com/894/node-cleanup-webhook/pkg/apis/generated/clientset/versioned/scheme"
+	v1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/infra/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NodeCleanupsGetter has a method to return a NodeCleanupInterface.
+// A group's client should implement this interface.
+type NodeCleanupsGetter interface {
+	NodeCleanups() NodeCleanupInterface
+}
+
+// NodeCleanupInterface has methods to work with NodeCleanup resources.
+type NodeCleanupInterface interface {
+	Create(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.CreateOptions) (*v1alpha1.NodeCleanup, error)
+	Update(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.UpdateOptions) (*v1alpha1.NodeCleanup, error)
+	UpdateStatus(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.UpdateOptions) (*v1alpha1.NodeCleanup, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.NodeCleanup, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.NodeCleanupList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.NodeCleanup, err error)
+	NodeCleanupExpansion
+}
+
+// nodeCleanups implements NodeCleanupInterface
+type nodeCleanups struct {
+	client rest.Interface
+}
+
+// newNodeCleanups returns a NodeCleanups
+func newNodeCleanups(c *InfraV1alpha1Client) *nodeCleanups {
+	return &nodeCleanups{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the nodeCleanup, and returns the corresponding nodeCleanup object, and an error if there is any.
+func (c *nodeCleanups) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.NodeCleanup, err error) {
+	result = &v1alpha1.NodeCleanup{}
+	err = c.client.Get().
+		Resource("nodecleanups").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NodeCleanups that match those selectors.
+func (c *nodeCleanups) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.NodeCleanupList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.NodeCleanupList{}
+	err = c.client.Get().
+		Resource("nodecleanups").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested nodeCleanups.
+func (c *nodeCleanups) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("nodecleanups").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a nodeCleanup and creates it.  Returns the server's representation of the nodeCleanup, and an error, if there is any.
+func (c *nodeCleanups) Create(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.CreateOptions) (result *v1alpha1.NodeCleanup, err error) {
+	result = &v1alpha1.NodeCleanup{}
+	err = c.client.Post().
+		Resource("nodecleanups").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeCleanup).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a nodeCleanup and updates it. Returns the server's representation of the nodeCleanup, and an error, if there is any.
+func (c *nodeCleanups) Update(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.UpdateOptions) (result *v1alpha1.NodeCleanup, err error) {
+	result = &v1alpha1.NodeCleanup{}
+	err = c.client.Put().
+		Resource("nodecleanups").
+		Name(nodeCleanup.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeCleanup).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *nodeCleanups) UpdateStatus(ctx context.Context, nodeCleanup *v1alpha1.NodeCleanup, opts v1.UpdateOptions) (result *v1alpha1.NodeCleanup, err error) {
+	result = &v1alpha1.NodeCleanup{}
+	err = c.client.Put().
+		Resource("nodecleanups").
+		Name(nodeCleanup.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeCleanup).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the nodeCleanup and deletes it. Returns an error if one occurs.
+func (c *nodeCleanups) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("nodecleanups").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *nodeCleanups) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("nodecleanups").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched nodeCleanup.
+func (c *nodeCleanups) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.NodeCleanup, err error) {
+	result = &v1alpha1.NodeCleanup{}
+	err = c.client.Patch(pt).
+		Resource("nodecleanups").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
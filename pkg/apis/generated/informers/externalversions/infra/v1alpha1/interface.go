@@ -0,0 +1,29 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	internalinterfaces "github.com/894/node-cleanup-webhook/pkg/apis/generated/informers/externalversions/internalinterfaces"
+)
+
+// Interface provides access to all the informers in this group version.
+type Interface interface {
+	// NodeCleanups returns a NodeCleanupInformer.
+	NodeCleanups() NodeCleanupInformer
+}
+
+type version struct {
+	factory          internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakListOptions internalinterfaces.TweakListOptionsFunc) Interface {
+	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
+}
+
+// NodeCleanups returns a NodeCleanupInformer.
+func (v *version) NodeCleanups() NodeCleanupInformer {
+	return &nodeCleanupInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,73 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	versioned "github.com/894/node-cleanup-webhook/pkg/apis/generated/clientset/versioned"
+	internalinterfaces "github.com/894/node-cleanup-webhook/pkg/apis/generated/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/generated/listers/infra/v1alpha1"
+	infrav1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/infra/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// NodeCleanupInformer provides access to a shared informer and lister for
+// NodeCleanups.
+type NodeCleanupInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.NodeCleanupLister
+}
+
+type nodeCleanupInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewNodeCleanupInformer constructs a new informer for NodeCleanup type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewNodeCleanupInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredNodeCleanupInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredNodeCleanupInformer constructs a new informer for NodeCleanup type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredNodeCleanupInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.InfraV1alpha1().NodeCleanups().List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.InfraV1alpha1().NodeCleanups().Watch(context.TODO(), options)
+			},
+		},
+		&infrav1alpha1.NodeCleanup{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *nodeCleanupInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredNodeCleanupInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *nodeCleanupInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&infrav1alpha1.NodeCleanup{}, f.defaultInformer)
+}
+
+func (f *nodeCleanupInformer) Lister() v1alpha1.NodeCleanupLister {
+	return v1alpha1.NewNodeCleanupLister(f.Informer().GetIndexer())
+}
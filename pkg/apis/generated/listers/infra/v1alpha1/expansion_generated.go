@@ -0,0 +1,7 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+// NodeCleanupListerExpansion allows custom methods to be added to
+// NodeCleanupLister.
+type NodeCleanupListerExpansion interface{}
@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/infra/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeCleanupLister helps list NodeCleanups.
+// All objects returned here must be treated as read-only.
+type NodeCleanupLister interface {
+	// List lists all NodeCleanups in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.NodeCleanup, err error)
+	// Get retrieves the NodeCleanup from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.NodeCleanup, error)
+	NodeCleanupListerExpansion
+}
+
+// nodeCleanupLister implements the NodeCleanupLister interface.
+type nodeCleanupLister struct {
+	indexer cache.Indexer
+}
+
+// NewNodeCleanupLister returns a new NodeCleanupLister.
+func NewNodeCleanupLister(indexer cache.Indexer) NodeCleanupLister {
+	return &nodeCleanupLister{indexer: indexer}
+}
+
+// List lists all NodeCleanups in the indexer.
+func (s *nodeCleanupLister) List(selector labels.Selector) (ret []*v1alpha1.NodeCleanup, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.NodeCleanup))
+	})
+	return ret, err
+}
+
+// Get retrieves the NodeCleanup from the index for a given name.
+func (s *nodeCleanupLister) Get(name string) (*v1alpha1.NodeCleanup, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("nodecleanup"), name)
+	}
+	return obj.(*v1alpha1.NodeCleanup), nil
+}
@@ -0,0 +1,6 @@
+// Package v1alpha1 contains the infra.894.io/v1alpha1 API types used to
+// record node cleanup lifecycles.
+//
+// +k8s:deepcopy-gen=package
+// +groupName=infra.894.io
+package v1alpha1
@@ -0,0 +1,112 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NodeCleanupPhase is the lifecycle phase of a node cleanup
+type NodeCleanupPhase string
+
+// Node cleanup phases
+const (
+	// NodeCleanupPending means the node entered deletion and cleanup has not started
+	NodeCleanupPending NodeCleanupPhase = "Pending"
+	// NodeCleanupAwaitingApproval means cleanup waits for manual approval
+	NodeCleanupAwaitingApproval NodeCleanupPhase = "AwaitingApproval"
+	// NodeCleanupRunning means cleanup plugins are executing
+	NodeCleanupRunning NodeCleanupPhase = "Running"
+	// NodeCleanupFailed means the last attempt failed; it may still be retried
+	NodeCleanupFailed NodeCleanupPhase = "Failed"
+	// NodeCleanupSucceeded means all plugins completed and the finalizer was removed
+	NodeCleanupSucceeded NodeCleanupPhase = "Succeeded"
+)
+
+// PluginPhase is the outcome of a single plugin within a cleanup
+type PluginPhase string
+
+// Plugin phases
+const (
+	PluginRunning   PluginPhase = "Running"
+	PluginSucceeded PluginPhase = "Succeeded"
+	PluginFailed    PluginPhase = "Failed"
+	PluginTimedOut  PluginPhase = "TimedOut"
+	PluginSkipped   PluginPhase = "Skipped"
+	// The plugin would run; recorded in dry-run mode
+	PluginPlanned PluginPhase = "Planned"
+	// The plugin's completed cleanup was undone after a permanent failure
+	PluginRolledBack     PluginPhase = "RolledBack"
+	PluginRollbackFailed PluginPhase = "RollbackFailed"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeCleanup records the cleanup lifecycle of a node that entered deletion.
+// It is cluster-scoped and named after the node.
+type NodeCleanup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodeCleanupSpec   `json:"spec"`
+	Status NodeCleanupStatus `json:"status,omitempty"`
+}
+
+// NodeCleanupSpec identifies the node being cleaned up
+type NodeCleanupSpec struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// NodeUID is the UID of the node, distinguishing nodes that reuse a name
+	NodeUID types.UID `json:"nodeUID"`
+	// DeletionTimestamp is when deletion of the node was requested
+	DeletionTimestamp metav1.Time `json:"deletionTimestamp"`
+}
+
+// NodeCleanupStatus is the observed state of a node cleanup
+type NodeCleanupStatus struct {
+	// Phase is the current lifecycle phase
+	Phase NodeCleanupPhase `json:"phase,omitempty"`
+	// Attempts is the number of cleanup attempts started so far
+	Attempts int32 `json:"attempts,omitempty"`
+	// StartTime is when the first attempt started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// LastAttemptTime is when the most recent attempt started
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// CompletionTime is when the cleanup succeeded
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// LastError is the error of the most recent failed attempt
+	LastError string `json:"lastError,omitempty"`
+	// ApprovalRequiredBy lists why the cleanup needs manual approval
+	ApprovalRequiredBy []string `json:"approvalRequiredBy,omitempty"`
+	// ApprovedBy is the user who approved the cleanup
+	ApprovedBy string `json:"approvedBy,omitempty"`
+	// ApprovalTime is when the approval was observed
+	ApprovalTime *metav1.Time `json:"approvalTime,omitempty"`
+	// Plugins holds the latest result of each plugin, in execution order
+	Plugins []PluginResult `json:"plugins,omitempty"`
+}
+
+// PluginResult is the latest outcome of one plugin for this cleanup
+type PluginResult struct {
+	// Name is the plugin name
+	Name string `json:"name"`
+	// Phase is the plugin outcome
+	Phase PluginPhase `json:"phase"`
+	// StartTime is when the plugin last started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is when the plugin last finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Message explains a failure or skip
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeCleanupList is a list of NodeCleanup resources
+type NodeCleanupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NodeCleanup `json:"items"`
+}
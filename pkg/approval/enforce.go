@@ -0,0 +1,60 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// CheckEnforced verifies that the ValidatingWebhookConfiguration configName
+// sends node UPDATEs to the webhook's path. Only that webhook makes an
+// approval name the user who set it; without it anyone allowed to annotate a
+// node can approve its cleanup on someone else's behalf.
+func CheckEnforced(ctx context.Context, client kubernetes.Interface, configName, path string) error {
+	config, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, configName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot read ValidatingWebhookConfiguration %s: %w", configName, err)
+	}
+
+	for _, webhook := range config.Webhooks {
+		if !validatesNodeUpdates(webhook, path) {
+			continue
+		}
+		if webhook.FailurePolicy == nil || *webhook.FailurePolicy == admissionregistrationv1.Ignore {
+			klog.Warningf("Webhook %s fails open: approvals are not checked while the webhook is unavailable", webhook.Name)
+		}
+		return nil
+	}
+	return fmt.Errorf("ValidatingWebhookConfiguration %s has no webhook for node UPDATE on %s", configName, path)
+}
+
+// validatesNodeUpdates reports whether the webhook receives node UPDATEs at path
+func validatesNodeUpdates(webhook admissionregistrationv1.ValidatingWebhook, path string) bool {
+	service := webhook.ClientConfig.Service
+	if service == nil || service.Path == nil || *service.Path != path {
+		return false
+	}
+
+	for _, rule := range webhook.Rules {
+		if contains(rule.Operations, admissionregistrationv1.Update, admissionregistrationv1.OperationAll) &&
+			contains(rule.Resources, "nodes", "*") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains[T comparable](values []T, wanted ...T) bool {
+	for _, value := range values {
+		for _, w := range wanted {
+			if value == w {
+				return true
+			}
+		}
+	}
+	return false
+}
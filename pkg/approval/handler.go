@@ -0,0 +1,113 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/894/node-cleanup-webhook/pkg/adminapi"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// Handler serves POST /approve-cleanup?node=<name>, an alternative to setting
+// the approval annotation by hand. The caller authenticates with a Kubernetes
+// bearer token, is authorized like a subresource request (update on
+// nodecleanups/approval for the node) and is recorded as the approver.
+type Handler struct {
+	client kubernetes.Interface
+}
+
+// NewHandler creates the approval API handler
+func NewHandler(client kubernetes.Interface) *Handler {
+	return &Handler{client: client}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nodeName := r.URL.Query().Get("node")
+	if nodeName == "" {
+		http.Error(w, "missing node parameter", http.StatusBadRequest)
+		return
+	}
+
+	username, ok := adminapi.Authorize(w, r, h.client, authorizationv1.ResourceAttributes{
+		Verb:        "update",
+		Group:       constants.ApprovalGroup,
+		Resource:    constants.ApprovalResource,
+		Subresource: constants.ApprovalSubresource,
+		Name:        nodeName,
+	})
+	if !ok {
+		return
+	}
+
+	if err := h.approve(r.Context(), nodeName, username); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("node %s not found", nodeName), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, errNotTerminating) {
+			http.Error(w, fmt.Sprintf("node %s is not being deleted; approve it after deleting it", nodeName), http.StatusConflict)
+			return
+		}
+		klog.ErrorS(err, "Failed to record cleanup approval", "node", nodeName, "user", username)
+		http.Error(w, "failed to record approval", http.StatusInternalServerError)
+		return
+	}
+
+	klog.InfoS("Cleanup approved through API", "node", nodeName, "user", username)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "cleanup of node %s approved by %s\n", nodeName, username)
+}
+
+// errNotTerminating refuses approvals of nodes that are not being deleted,
+// which would not count
+var errNotTerminating = errors.New("node is not being deleted")
+
+// approve records username in the node's approval annotation. The webhook
+// lets the controller set it on the approver's behalf.
+func (h *Handler) approve(ctx context.Context, nodeName, username string) error {
+	node, err := h.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if node.DeletionTimestamp == nil {
+		return errNotTerminating
+	}
+
+	// Setting the same value again changes nothing and would leave the
+	// approval dated before the deletion
+	if StaleApprover(node) == username {
+		if err := h.patchApprover(ctx, nodeName, nil); err != nil {
+			return err
+		}
+	}
+	return h.patchApprover(ctx, nodeName, &username)
+}
+
+// patchApprover sets the approval annotation, or removes it when approver is nil
+func (h *Handler) patchApprover(ctx context.Context, nodeName string, approver *string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]*string{
+				constants.CleanupApprovedByAnnotation: approver,
+			},
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	_, err = h.client.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	return err
+}
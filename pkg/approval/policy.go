@@ -0,0 +1,137 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Policy decides which terminating nodes wait for a human to approve their
+// cleanup and what happens when nobody does in time. Approval is required
+// when the node matches the approval selector or when an enabled plugin that
+// would run for it requires approval (see plugins.Registry.ApprovalRequiredBy).
+type Policy struct {
+	selector       labels.Selector // nil: no node requires approval by its labels
+	timeout        time.Duration   // 0: wait forever
+	timeoutAction  string
+	pluginRegistry *plugins.Registry
+}
+
+// New parses the approval configuration. An empty selector requires no
+// approval by label; plugins may still require it.
+func New(selector string, timeout time.Duration, timeoutAction string, pluginRegistry *plugins.Registry) (*Policy, error) {
+	policy := &Policy{timeout: timeout, timeoutAction: timeoutAction, pluginRegistry: pluginRegistry}
+	if strings.TrimSpace(selector) != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid approval node selector %q: %w", selector, err)
+		}
+		policy.selector = parsed
+	}
+
+	switch timeoutAction {
+	case constants.ApprovalTimeoutEscalate, constants.ApprovalTimeoutProceed:
+	default:
+		return nil, fmt.Errorf("invalid approval timeout action %q", timeoutAction)
+	}
+	return policy, nil
+}
+
+// RequiredBy explains why the node's cleanup needs approval, or returns nil
+// when it does not
+func (p *Policy) RequiredBy(node *corev1.Node) []string {
+	var reasons []string
+	if p.selector != nil && p.selector.Matches(labels.Set(node.Labels)) {
+		reasons = append(reasons, fmt.Sprintf("node selector %q", p.selector.String()))
+	}
+	for _, name := range p.pluginRegistry.ApprovalRequiredBy(node) {
+		reasons = append(reasons, "plugin "+name)
+	}
+	return reasons
+}
+
+// TimedOut returns how long the node has been terminating and whether that
+// exceeds the approval timeout
+func (p *Policy) TimedOut(node *corev1.Node) (time.Duration, bool) {
+	elapsed := time.Since(node.DeletionTimestamp.Time)
+	return elapsed, p.timeout > 0 && elapsed >= p.timeout
+}
+
+// ProceedOnTimeout reports whether cleanup runs unapproved once the timeout
+// passes, rather than escalating and waiting on
+func (p *Policy) ProceedOnTimeout() bool {
+	return p.timeoutAction == constants.ApprovalTimeoutProceed
+}
+
+// Enabled reports whether any node's cleanup can require approval
+func (p *Policy) Enabled() bool {
+	return p.selector != nil || p.pluginRegistry.MayRequireApproval()
+}
+
+// Approver returns who approved the node's cleanup, or "" if nobody has. An
+// approval set before the node was deleted (at creation, by a template or for
+// an earlier deletion attempt) does not count, see StaleApprover.
+func Approver(node *corev1.Node) string {
+	approver := strings.TrimSpace(node.Annotations[constants.CleanupApprovedByAnnotation])
+	if approver == "" || setBeforeDeletion(node) {
+		return ""
+	}
+	return approver
+}
+
+// StaleApprover returns the approver recorded before the node was deleted,
+// whose approval is ignored, or ""
+func StaleApprover(node *corev1.Node) string {
+	approver := strings.TrimSpace(node.Annotations[constants.CleanupApprovedByAnnotation])
+	if approver == "" || !setBeforeDeletion(node) {
+		return ""
+	}
+	return approver
+}
+
+// setBeforeDeletion reports whether the approval annotation was last set
+// before the node's DeletionTimestamp. The time comes from the node's managed
+// fields, which the API server maintains; without them the approval counts.
+func setBeforeDeletion(node *corev1.Node) bool {
+	if node.DeletionTimestamp == nil {
+		return true
+	}
+	setAt, ok := annotationSetAt(node, constants.CleanupApprovedByAnnotation)
+	return ok && setAt.Before(node.DeletionTimestamp.Time)
+}
+
+// annotationSetAt returns when an annotation was last written, according to
+// the latest managed fields entry owning it
+func annotationSetAt(node *corev1.Node, annotation string) (time.Time, bool) {
+	var setAt time.Time
+	found := false
+	for _, entry := range node.ManagedFields {
+		if entry.FieldsV1 == nil || entry.Time == nil {
+			continue
+		}
+
+		var fields struct {
+			Metadata struct {
+				Annotations map[string]json.RawMessage `json:"f:annotations"`
+			} `json:"f:metadata"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, owned := fields.Metadata.Annotations["f:"+annotation]; !owned {
+			continue
+		}
+
+		if !found || entry.Time.After(setAt) {
+			setAt = entry.Time.Time
+		}
+		found = true
+	}
+	return setAt, found
+}
@@ -0,0 +1,137 @@
+// Package audit keeps an append-only record of every cleanup decision: one
+// JSON object per line, written to a rotated file or to stdout, separate from
+// the klog output on stderr.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Audit event names
+const (
+	EventFinalizerAdded         = "finalizer_added"
+	EventFinalizerRemoved       = "finalizer_removed"
+	EventFinalizerForceReleased = "finalizer_force_released"
+	EventCleanupStarted         = "cleanup_started"
+	EventCleanupSucceeded       = "cleanup_succeeded"
+	EventCleanupFailed          = "cleanup_failed"
+	EventCleanupSkipped         = "cleanup_skipped"
+	EventCleanupApproved        = "cleanup_approved"
+	EventCleanupDeferred        = "cleanup_deferred"
+	EventCleanupPaused          = "cleanup_paused"
+	EventPluginStarted          = "plugin_started"
+	EventPluginFinished         = "plugin_finished"
+	EventPluginRolledBack       = "plugin_rolled_back"
+)
+
+// Outcomes of plugin_finished, plugin_rolled_back and cleanup_failed
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeTimedOut  = "timed_out"
+	OutcomeSkipped   = "skipped"
+	OutcomeRetry     = "retry"
+	OutcomeExhausted = "exhausted"
+)
+
+// Stdout as the destination writes records to standard output
+const Stdout = "stdout"
+
+// Record is one audit line
+type Record struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Node    string    `json:"node"`
+	NodeUID string    `json:"nodeUID,omitempty"`
+	Plugin  string    `json:"plugin,omitempty"`
+	Outcome string    `json:"outcome,omitempty"`
+	// Cleanup attempt, counting from 1
+	Attempt int `json:"attempt,omitempty"`
+	// User who approved or requested the action, when known
+	Actor  string `json:"actor,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Plugin run time
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+}
+
+// Log writes audit records. A Log without destination discards them.
+type Log struct {
+	mu          sync.Mutex
+	out         io.Writer
+	closer      io.Closer
+	destination string
+}
+
+// New opens the audit log. destination is "" to disable it, Stdout, or the
+// path of a file that is rotated when it would exceed maxSizeBytes, keeping
+// maxBackups old files (path.1 being the newest).
+func New(destination string, maxSizeBytes int64, maxBackups int) (*Log, error) {
+	switch destination {
+	case "":
+		return &Log{}, nil
+	case Stdout:
+		return &Log{out: os.Stdout, destination: destination}, nil
+	}
+
+	file, err := openRotatingFile(destination, maxSizeBytes, maxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", destination, err)
+	}
+	return &Log{out: file, closer: file, destination: destination}, nil
+}
+
+// Enabled reports whether records are written anywhere
+func (l *Log) Enabled() bool {
+	return l != nil && l.out != nil
+}
+
+// Record writes r as one line, stamping the current time if r has none.
+// Write errors are logged; cleanup does not wait for the audit log.
+func (l *Log) Record(r Record) {
+	if !l.Enabled() {
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC()
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode audit record", "event", r.Event, "node", r.Node)
+		return
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.out.Write(line); err != nil {
+		klog.ErrorS(err, "Failed to write audit record", "destination", l.destination, "event", r.Event, "node", r.Node)
+	}
+}
+
+// Close flushes and closes an audit log file
+func (l *Log) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closer.Close()
+}
+
+// String describes the destination for logs
+func (l *Log) String() string {
+	if !l.Enabled() {
+		return "disabled"
+	}
+	return l.destination
+}
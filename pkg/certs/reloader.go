@@ -0,0 +1,138 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Reloader serves the webhook certificate through tls.Config.GetCertificate
+// and swaps in a new one when the files on disk change, so a rotated secret
+// takes effect without restarting the pod.
+//
+// The files are polled rather than watched with inotify: Kubernetes updates a
+// mounted secret by swapping a symlink, and polling the contents sees that
+// the same way on every volume type.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	current atomic.Pointer[tls.Certificate]
+
+	// Contents last read from disk, valid or not. Only accessed by
+	// NewReloader and the Run loop.
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// NewReloader loads the initial certificate. It fails if the pair is invalid
+// since the webhook cannot serve without one.
+func NewReloader(certFile, keyFile string, interval time.Duration) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	changed, err := r.reload()
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, fmt.Errorf("no certificate loaded from %s", certFile)
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.current.Load(), nil
+}
+
+// Certificate returns the certificate currently served
+func (r *Reloader) Certificate() *x509.Certificate {
+	return r.current.Load().Leaf
+}
+
+// Run checks the files for changes every interval until ctx is done. An
+// invalid pair is logged and the previous certificate keeps being served.
+func (r *Reloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.reload(); err != nil {
+				klog.ErrorS(err, "Refusing to reload webhook certificate - keeping the current one",
+					"certFile", r.certFile, "keyFile", r.keyFile)
+			}
+		}
+	}
+}
+
+// reload reads both files and swaps in the pair when their contents changed.
+// It returns whether a new certificate is now served.
+func (r *Reloader) reload() (bool, error) {
+	certPEM, err := os.ReadFile(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read key: %w", err)
+	}
+	if bytes.Equal(certPEM, r.certPEM) && bytes.Equal(keyPEM, r.keyPEM) {
+		return false, nil
+	}
+	// Remember the contents even if they are invalid so a bad pair is
+	// reported once, not on every poll. A pair read mid-rotation differs
+	// again on the next poll and is retried.
+	r.certPEM, r.keyPEM = certPEM, keyPEM
+
+	cert, err := parsePair(certPEM, keyPEM, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	r.current.Store(cert)
+	klog.InfoS("Loaded webhook certificate", "subject", cert.Leaf.Subject.String(),
+		"notAfter", cert.Leaf.NotAfter.UTC().Format(time.RFC3339),
+		"expiresIn", time.Until(cert.Leaf.NotAfter).Round(time.Minute))
+	return true, nil
+}
+
+// parsePair checks that the key matches the certificate and that the
+// certificate is valid at now
+func parsePair(certPEM, keyPEM []byte, now time.Time) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate/key pair: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse certificate: %w", err)
+	}
+	if err := CheckValidity(leaf, now); err != nil {
+		return nil, err
+	}
+	cert.Leaf = leaf
+	return &cert, nil
+}
+
+// CheckValidity returns an error when now is outside the certificate's
+// validity period
+func CheckValidity(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("certificate not valid before %s", cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/config"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// Secret keys besides tls.crt and tls.key
+const (
+	caCertKey   = "ca.crt"
+	caKeyKey    = "ca.key"
+	caBundleKey = "ca-bundle.crt" // Current CA followed by unexpired previous CAs
+)
+
+// Manager replaces cert-manager on clusters that do not run it. It keeps a
+// CA and a serving certificate in a Secret, rotates both when less than a
+// third of their lifetime remains, writes the serving pair to the files the
+// Reloader serves and injects the CA bundle into the webhook configurations.
+//
+// Every replica runs a Manager. The Secret is the single source of truth:
+// whichever replica renews first wins and the others pick up its result.
+type Manager struct {
+	client            kubernetes.Interface
+	namespace         string
+	secretName        string
+	webhookConfigName string
+	dnsNames          []string
+	certFile          string
+	keyFile           string
+}
+
+// NewManager creates a Manager for the webhook Service in cfg.Namespace
+func NewManager(client kubernetes.Interface, cfg *config.Config) *Manager {
+	service := cfg.WebhookServiceName
+	return &Manager{
+		client:            client,
+		namespace:         cfg.Namespace,
+		secretName:        cfg.CertSecretName,
+		webhookConfigName: cfg.WebhookConfigName,
+		dnsNames: []string{
+			fmt.Sprintf("%s.%s.svc", service, cfg.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", service, cfg.Namespace),
+			fmt.Sprintf("%s.%s", service, cfg.Namespace),
+			service,
+		},
+		certFile: cfg.TLSCertFile,
+		keyFile:  cfg.TLSKeyFile,
+	}
+}
+
+// Sync renews the Secret if needed, writes the serving certificate files and
+// makes sure the webhook configurations trust the CA
+func (m *Manager) Sync(ctx context.Context) error {
+	data, err := m.ensureSecret(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update certificate secret %s/%s: %w", m.namespace, m.secretName, err)
+	}
+
+	if err := writeFileIfChanged(m.certFile, data[corev1.TLSCertKey]); err != nil {
+		return err
+	}
+	if err := writeFileIfChanged(m.keyFile, data[corev1.TLSPrivateKeyKey]); err != nil {
+		return err
+	}
+
+	return m.injectCABundle(ctx, data[caBundleKey])
+}
+
+// Run syncs every interval until ctx is done
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Sync(ctx); err != nil {
+				klog.ErrorS(err, "Failed to sync self-managed certificates - will retry")
+			}
+		}
+	}
+}
+
+// ensureSecret creates or renews the certificate Secret and returns its data
+func (m *Manager) ensureSecret(ctx context.Context) (map[string][]byte, error) {
+	secrets := m.client.CoreV1().Secrets(m.namespace)
+
+	// Another replica may create or renew the Secret at the same time
+	lostRace := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}
+
+	var data map[string][]byte
+	err := retry.OnError(retry.DefaultRetry, lostRace, func() error {
+		secret, err := secrets.Get(ctx, m.secretName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			renewed, _, err := m.renew(nil, time.Now())
+			if err != nil {
+				return err
+			}
+			_, err = secrets.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      m.secretName,
+					Namespace: m.namespace,
+					Labels:    map[string]string{"app.kubernetes.io/name": constants.EventComponent},
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: renewed,
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+			klog.InfoS("Created self-managed certificate secret", "secret", m.secretName, "namespace", m.namespace)
+			data = renewed
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		renewed, changed, err := m.renew(secret.Data, time.Now())
+		if err != nil {
+			return err
+		}
+		if changed {
+			secret.Data = renewed
+			if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+		data = renewed
+		return nil
+	})
+	return data, err
+}
+
+// renew returns data with a CA and serving certificate valid at now. A
+// missing, unparseable or expiring CA is replaced, and the serving
+// certificate is reissued when it expires, no longer matches the Service
+// names or was signed by a replaced CA.
+func (m *Manager) renew(data map[string][]byte, now time.Time) (map[string][]byte, bool, error) {
+	renewed := make(map[string][]byte, len(data)+5)
+	for key, value := range data {
+		renewed[key] = value
+	}
+	changed := false
+
+	ca, err := parseKeyPair(data[caCertKey], data[caKeyKey])
+	if err != nil || !ca.cert.IsCA || needsRenewal(ca.cert, now) {
+		if err == nil {
+			klog.InfoS("Rotating self-managed webhook CA", "notAfter", ca.cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+		ca, err = newCA(constants.EventComponent+"-ca", now, constants.SelfManagedCAValidity)
+		if err != nil {
+			return nil, false, err
+		}
+		renewed[caCertKey], renewed[caKeyKey] = ca.certPEM, ca.keyPEM
+		changed = true
+	}
+
+	bundle := caBundle(ca, data[caBundleKey], now)
+	if !bytes.Equal(bundle, data[caBundleKey]) {
+		renewed[caBundleKey] = bundle
+		changed = true
+	}
+
+	serving, err := parseKeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if err != nil || needsRenewal(serving.cert, now) || !coversNames(serving.cert, m.dnsNames) ||
+		serving.cert.CheckSignatureFrom(ca.cert) != nil {
+		serving, err = newServingCert(ca, m.dnsNames, now, constants.SelfManagedCertValidity)
+		if err != nil {
+			return nil, false, err
+		}
+		renewed[corev1.TLSCertKey], renewed[corev1.TLSPrivateKeyKey] = serving.certPEM, serving.keyPEM
+		klog.InfoS("Issued self-managed webhook certificate", "dnsNames", m.dnsNames,
+			"notAfter", serving.cert.NotAfter.UTC().Format(time.RFC3339))
+		changed = true
+	}
+
+	return renewed, changed, nil
+}
+
+// injectCABundle sets the caBundle of every webhook in the mutating and
+// validating configurations. A configuration that does not exist (yet) is
+// skipped; the next sync picks it up.
+func (m *Manager) injectCABundle(ctx context.Context, bundle []byte) error {
+	admission := m.client.AdmissionregistrationV1()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		webhookConfig, err := admission.MutatingWebhookConfigurations().Get(ctx, m.webhookConfigName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		clientConfigs := make([]*admissionregistrationv1.WebhookClientConfig, 0, len(webhookConfig.Webhooks))
+		for i := range webhookConfig.Webhooks {
+			clientConfigs = append(clientConfigs, &webhookConfig.Webhooks[i].ClientConfig)
+		}
+		if !setCABundle(clientConfigs, bundle) {
+			return nil
+		}
+		_, err = admission.MutatingWebhookConfigurations().Update(ctx, webhookConfig, metav1.UpdateOptions{})
+		if err == nil {
+			klog.InfoS("Injected CA bundle", "mutatingWebhookConfiguration", m.webhookConfigName)
+		}
+		return err
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to inject CA bundle into MutatingWebhookConfiguration %s: %w", m.webhookConfigName, err)
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		webhookConfig, err := admission.ValidatingWebhookConfigurations().Get(ctx, m.webhookConfigName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		clientConfigs := make([]*admissionregistrationv1.WebhookClientConfig, 0, len(webhookConfig.Webhooks))
+		for i := range webhookConfig.Webhooks {
+			clientConfigs = append(clientConfigs, &webhookConfig.Webhooks[i].ClientConfig)
+		}
+		if !setCABundle(clientConfigs, bundle) {
+			return nil
+		}
+		_, err = admission.ValidatingWebhookConfigurations().Update(ctx, webhookConfig, metav1.UpdateOptions{})
+		if err == nil {
+			klog.InfoS("Injected CA bundle", "validatingWebhookConfiguration", m.webhookConfigName)
+		}
+		return err
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to inject CA bundle into ValidatingWebhookConfiguration %s: %w", m.webhookConfigName, err)
+	}
+	return nil
+}
+
+// setCABundle sets bundle on every client config and reports whether any changed
+func setCABundle(clientConfigs []*admissionregistrationv1.WebhookClientConfig, bundle []byte) bool {
+	changed := false
+	for _, clientConfig := range clientConfigs {
+		if !bytes.Equal(clientConfig.CABundle, bundle) {
+			clientConfig.CABundle = bundle
+			changed = true
+		}
+	}
+	return changed
+}
+
+// writeFileIfChanged replaces the file atomically when its contents differ,
+// so the Reloader never reads a partial write
+func writeFileIfChanged(path string, data []byte) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
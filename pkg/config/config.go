@@ -1,11 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/894/node-cleanup-webhook/pkg/constants"
 	"k8s.io/klog/v2"
 )
 
@@ -17,12 +19,88 @@ type Config struct {
 	Port        int
 	Kubeconfig  string
 
+	// Answer when an admission handler fails internally, matching the
+	// webhook configurations' failurePolicy: "Ignore" allows, "Fail" denies
+	MutateFailurePolicy   string
+	ValidateFailurePolicy string
+
+	// Self-managed certificates: generate a CA and serving certificate, keep
+	// them in CertSecretName and inject the CA into WebhookConfigName
+	SelfManagedCerts   bool
+	CertSecretName     string
+	WebhookServiceName string
+	WebhookConfigName  string
+	Namespace          string // Namespace the webhook runs in
+
+	// Service account the webhook runs as; only it may remove the finalizer
+	ServiceAccountName string
+
+	// Metrics configuration (0 disables the metrics server)
+	MetricsPort int
+
 	// Kubernetes client configuration
 	InsecureSkipTLSVerify bool // Skip TLS verification for kube-apiserver (insecure environments)
 
+	// Nodes that get the cleanup finalizer (see pkg/scope)
+	NodeSelector           string   // Label selector, empty selects all nodes
+	NodeExcludeTaints      []string // "key" or "key=value"
+	NodeExcludeAnnotations []string // "key" or "key=value"
+
+	// Watcher configuration
+	Workers int  // Number of nodes cleaned up concurrently
+	DryRun  bool // Only plan cleanups; never run plugins or add finalizers
+
+	// Cleanup deadline tiers measured from DeletionTimestamp (0 disables a tier)
+	CleanupWarnAfter         time.Duration // Warning event
+	CleanupAlertAfter        time.Duration // Warning event and overdue annotation
+	CleanupForceReleaseAfter time.Duration // Finalizer removed without completing cleanup
+
+	// NodeCleanup records of deleted nodes are deleted after this long (0 keeps them)
+	NodeCleanupRetention time.Duration
+
+	// Manual approval before cleanup: nodes matching ApprovalNodeSelector, or
+	// run by a plugin with requireApproval, wait for the approval annotation.
+	// After ApprovalTimeout (0 = wait forever) ApprovalTimeoutAction applies.
+	ApprovalNodeSelector  string // Label selector, empty requires no approval by label
+	ApprovalTimeout       time.Duration
+	ApprovalTimeoutAction string // "escalate" or "proceed"
+
+	// Maintenance schedule: cleanup only starts inside a window and not on a
+	// blackout date (see pkg/maintenance). No windows means any time.
+	MaintenanceWindows       []string // "<cron expression> <duration>"
+	MaintenanceTimezone      string   // IANA name, empty means UTC
+	MaintenanceBlackoutDates []string // "2006-01-02" or "2006-01-02..2006-01-05"
+
+	// Circuit breaker: pause new cleanups when more than BreakerMaxDeletions
+	// nodes, or BreakerMaxDeletionsPercent of the nodes with the finalizer,
+	// enter deletion within BreakerWindow, or when more than
+	// BreakerMaxCleanupsPerHour cleanups start within an hour (0 disables a
+	// limit). The state is kept in BreakerConfigMapName until reset.
+	BreakerMaxDeletions        int
+	BreakerMaxDeletionsPercent int
+	BreakerWindow              time.Duration
+	BreakerMaxCleanupsPerHour  int
+	BreakerConfigMapName       string
+
+	// Audit log of every cleanup decision (see pkg/audit): "" disables it,
+	// "stdout" writes to standard output, anything else is a file path
+	// rotated at AuditLogMaxSizeMB, keeping AuditLogMaxBackups old files
+	AuditLog           string
+	AuditLogMaxSizeMB  int
+	AuditLogMaxBackups int
+
+	// Leader election configuration (only the leader runs the watcher)
+	LeaderElect             bool
+	LeaderElectionID        string
+	LeaderElectionNamespace string
+	LeaseDuration           time.Duration
+	RenewDeadline           time.Duration
+	RetryPeriod             time.Duration
+
 	// Plugin configuration
 	EnabledPlugins []string
 	PluginConfigs  map[string]PluginConfig
+	PluginTimeout  time.Duration // Default Cleanup timeout per plugin, 0 = none
 }
 
 // PluginConfig holds configuration for a specific plugin
@@ -34,13 +112,53 @@ type PluginConfig struct {
 // LoadFromEnv loads configuration from environment variables
 func LoadFromEnv() *Config {
 	cfg := &Config{
-		TLSCertFile:           getEnv("TLS_CERT_FILE", "/etc/webhook/certs/tls.crt"),
-		TLSKeyFile:            getEnv("TLS_KEY_FILE", "/etc/webhook/certs/tls.key"),
-		Port:                  getEnvInt("PORT", 8443),
-		Kubeconfig:            getEnv("KUBECONFIG", ""),
-		InsecureSkipTLSVerify: getEnvBool("INSECURE_SKIP_TLS_VERIFY", false),
-		PluginConfigs:         make(map[string]PluginConfig),
-		EnabledPlugins:        []string{},
+		TLSCertFile:                getEnv("TLS_CERT_FILE", "/etc/webhook/certs/tls.crt"),
+		TLSKeyFile:                 getEnv("TLS_KEY_FILE", "/etc/webhook/certs/tls.key"),
+		Port:                       getEnvInt("PORT", 8443),
+		MetricsPort:                getEnvInt("METRICS_PORT", constants.DefaultMetricsPort),
+		Kubeconfig:                 getEnv("KUBECONFIG", ""),
+		MutateFailurePolicy:        getEnvFailurePolicy("MUTATE_FAILURE_POLICY"),
+		ValidateFailurePolicy:      getEnvFailurePolicy("VALIDATE_FAILURE_POLICY"),
+		SelfManagedCerts:           getEnvBool("SELF_MANAGED_CERTS", false),
+		CertSecretName:             getEnv("CERT_SECRET_NAME", constants.DefaultCertSecretName),
+		WebhookServiceName:         getEnv("WEBHOOK_SERVICE_NAME", constants.DefaultWebhookServiceName),
+		WebhookConfigName:          getEnv("WEBHOOK_CONFIG_NAME", constants.DefaultWebhookConfigName),
+		Namespace:                  getEnv("POD_NAMESPACE", constants.DefaultNamespace),
+		ServiceAccountName:         getEnv("SERVICE_ACCOUNT_NAME", constants.DefaultServiceAccountName),
+		InsecureSkipTLSVerify:      getEnvBool("INSECURE_SKIP_TLS_VERIFY", false),
+		NodeSelector:               getEnv("NODE_SELECTOR", ""),
+		NodeExcludeTaints:          getEnvList("NODE_EXCLUDE_TAINTS"),
+		NodeExcludeAnnotations:     getEnvList("NODE_EXCLUDE_ANNOTATIONS"),
+		Workers:                    getEnvInt("WATCHER_WORKERS", constants.DefaultWorkerCount),
+		DryRun:                     getEnvBool("DRY_RUN", false),
+		CleanupWarnAfter:           getEnvDuration("CLEANUP_WARN_AFTER", constants.DefaultCleanupWarnAfter),
+		CleanupAlertAfter:          getEnvDuration("CLEANUP_ALERT_AFTER", constants.DefaultCleanupAlertAfter),
+		CleanupForceReleaseAfter:   getEnvDuration("CLEANUP_FORCE_RELEASE_AFTER", constants.DefaultCleanupForceReleaseAfter),
+		NodeCleanupRetention:       getEnvDuration("NODECLEANUP_RETENTION", constants.DefaultNodeCleanupRetention),
+		ApprovalNodeSelector:       getEnv("APPROVAL_NODE_SELECTOR", ""),
+		ApprovalTimeout:            getEnvDuration("APPROVAL_TIMEOUT", 0),
+		ApprovalTimeoutAction:      getEnvApprovalTimeoutAction("APPROVAL_TIMEOUT_ACTION"),
+		MaintenanceWindows:         getEnvListSep("MAINTENANCE_WINDOWS", ";"),
+		MaintenanceTimezone:        getEnv("MAINTENANCE_TIMEZONE", "UTC"),
+		MaintenanceBlackoutDates:   getEnvList("MAINTENANCE_BLACKOUT_DATES"),
+		BreakerMaxDeletions:        getEnvInt("BREAKER_MAX_DELETIONS", 0),
+		BreakerMaxDeletionsPercent: getEnvInt("BREAKER_MAX_DELETIONS_PERCENT", 0),
+		BreakerWindow:              getEnvDuration("BREAKER_WINDOW", constants.DefaultBreakerWindow),
+		BreakerMaxCleanupsPerHour:  getEnvInt("BREAKER_MAX_CLEANUPS_PER_HOUR", 0),
+		BreakerConfigMapName:       getEnv("BREAKER_CONFIGMAP", constants.DefaultBreakerConfigMapName),
+		AuditLog:                   getEnv("AUDIT_LOG", ""),
+		AuditLogMaxSizeMB:          getEnvInt("AUDIT_LOG_MAX_SIZE_MB", constants.DefaultAuditLogMaxSizeMB),
+		AuditLogMaxBackups:         getEnvInt("AUDIT_LOG_MAX_BACKUPS", constants.DefaultAuditLogMaxBackups),
+		LeaderElect:                getEnvBool("LEADER_ELECT", true),
+		LeaderElectionID:           getEnv("LEADER_ELECTION_ID", constants.DefaultLeaderElectionID),
+		// Defaults to the namespace the pod runs in (downward API)
+		LeaderElectionNamespace: getEnv("LEADER_ELECTION_NAMESPACE", getEnv("POD_NAMESPACE", constants.DefaultNamespace)),
+		LeaseDuration:           getEnvDuration("LEADER_ELECTION_LEASE_DURATION", constants.DefaultLeaseDuration),
+		RenewDeadline:           getEnvDuration("LEADER_ELECTION_RENEW_DEADLINE", constants.DefaultRenewDeadline),
+		RetryPeriod:             getEnvDuration("LEADER_ELECTION_RETRY_PERIOD", constants.DefaultRetryPeriod),
+		PluginTimeout:           getEnvDuration("PLUGIN_TIMEOUT", constants.DefaultPluginTimeout),
+		PluginConfigs:           make(map[string]PluginConfig),
+		EnabledPlugins:          []string{},
 	}
 
 	// Load enabled plugins from ENABLED_PLUGINS env var
@@ -52,6 +170,11 @@ func LoadFromEnv() *Config {
 		}
 	}
 
+	if cfg.Workers < 1 {
+		klog.Warningf("Invalid WATCHER_WORKERS %d, using 1", cfg.Workers)
+		cfg.Workers = 1
+	}
+
 	// Load plugin-specific configurations
 	cfg.loadPluginConfigs()
 
@@ -64,8 +187,10 @@ func (c *Config) loadPluginConfigs() {
 	c.PluginConfigs["logger"] = PluginConfig{
 		Enabled: c.isPluginEnabled("logger"),
 		Options: map[string]string{
-			"format":    getEnv("LOGGER_FORMAT", "pretty"),
-			"verbosity": getEnv("LOGGER_VERBOSITY", "info"),
+			"format":    getEnv("LOGGER_FORMAT", constants.DefaultLoggerFormat),
+			"verbosity": getEnv("LOGGER_VERBOSITY", constants.DefaultLoggerVerbosity),
+			"delay":     getEnv("LOGGER_DELAY", ""),
+			"output":    getEnv("LOGGER_OUTPUT", c.defaultLoggerOutput()),
 		},
 	}
 
@@ -75,9 +200,37 @@ func (c *Config) loadPluginConfigs() {
 		Options: map[string]string{
 			"labelSelector": getEnv("PORTWORX_LABEL_SELECTOR", "px/enabled=true"),
 			"apiEndpoint":   getEnv("PORTWORX_API_ENDPOINT", "http://portworx-api:9001"),
-			"timeout":       getEnv("PORTWORX_TIMEOUT", "300s"),
+			"minNodes":      getEnv("PORTWORX_MIN_NODES", strconv.Itoa(constants.DefaultPortworxMinNodes)),
 		},
 	}
+
+	// Options every enabled plugin accepts, as <PLUGIN>_<OPTION>
+	for _, name := range c.EnabledPlugins {
+		pluginCfg, ok := c.PluginConfigs[name]
+		if !ok {
+			pluginCfg = PluginConfig{Enabled: true, Options: map[string]string{}}
+		}
+		prefix := strings.ToUpper(name) + "_"
+		if after, set := os.LookupEnv(prefix + "AFTER"); set {
+			pluginCfg.Options["after"] = after
+		}
+		if timeout := getEnv(prefix+"TIMEOUT", ""); timeout != "" {
+			pluginCfg.Options["timeout"] = timeout
+		}
+		if requireApproval := getEnv(prefix+"REQUIRE_APPROVAL", ""); requireApproval != "" {
+			pluginCfg.Options["requireApproval"] = requireApproval
+		}
+		c.PluginConfigs[name] = pluginCfg
+	}
+}
+
+// defaultLoggerOutput keeps the logger plugin off stdout when the audit log
+// writes there, so that the audit records stay one JSON object per line
+func (c *Config) defaultLoggerOutput() string {
+	if c.AuditLog == "stdout" {
+		return "stderr"
+	}
+	return "stdout"
 }
 
 // isPluginEnabled checks if a plugin is in the enabled list
@@ -100,6 +253,21 @@ func (c *Config) GetPluginOption(pluginName, optionName, defaultValue string) st
 	return defaultValue
 }
 
+// GetPluginOptionInt gets an integer configuration option for a plugin
+func (c *Config) GetPluginOptionInt(pluginName, optionName string, defaultValue int) int {
+	val := c.GetPluginOption(pluginName, optionName, "")
+	if val == "" {
+		return defaultValue
+	}
+
+	intVal, err := strconv.Atoi(val)
+	if err != nil {
+		klog.Warningf("Invalid integer for %s.%s: %s, using default %d", pluginName, optionName, val, defaultValue)
+		return defaultValue
+	}
+	return intVal
+}
+
 // GetPluginOptionDuration gets a duration configuration option for a plugin
 func (c *Config) GetPluginOptionDuration(pluginName, optionName string, defaultValue time.Duration) time.Duration {
 	val := c.GetPluginOption(pluginName, optionName, "")
@@ -115,14 +283,88 @@ func (c *Config) GetPluginOptionDuration(pluginName, optionName string, defaultV
 	return duration
 }
 
+// ServiceAccountUsername returns the API server username of the webhook's
+// service account
+func (c *Config) ServiceAccountUsername() string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", c.Namespace, c.ServiceAccountName)
+}
+
+// GetPluginTimeout returns the Cleanup timeout of a plugin: its "timeout"
+// option, or PluginTimeout when unset
+func (c *Config) GetPluginTimeout(pluginName string) time.Duration {
+	return c.GetPluginOptionDuration(pluginName, "timeout", c.PluginTimeout)
+}
+
+// GetPluginRequiresApproval returns the plugin's "requireApproval" option:
+// whether its cleanups wait for manual approval
+func (c *Config) GetPluginRequiresApproval(pluginName string) bool {
+	val := c.GetPluginOption(pluginName, "requireApproval", "")
+	if val == "" {
+		return false
+	}
+
+	required, err := strconv.ParseBool(val)
+	if err != nil {
+		klog.Warningf("Invalid boolean for %s.requireApproval: %s, using false", pluginName, val)
+		return false
+	}
+	return required
+}
+
+// GetPluginAfter returns the plugin's "after" option, the comma separated
+// plugins it must run after, and whether it is set
+func (c *Config) GetPluginAfter(pluginName string) ([]string, bool) {
+	val, ok := c.PluginConfigs[pluginName].Options["after"]
+	if !ok {
+		return nil, false
+	}
+
+	after := []string{}
+	for _, dep := range strings.Split(val, ",") {
+		if dep = strings.TrimSpace(dep); dep != "" {
+			after = append(after, dep)
+		}
+	}
+	return after, true
+}
+
 // Print prints the configuration
 func (c *Config) Print() {
 	klog.Info("Configuration:")
 	klog.Infof("  TLS Cert: %s", c.TLSCertFile)
 	klog.Infof("  TLS Key: %s", c.TLSKeyFile)
 	klog.Infof("  Port: %d", c.Port)
+	klog.Infof("  Failure Policy: mutate %s, validate %s", c.MutateFailurePolicy, c.ValidateFailurePolicy)
+	klog.Infof("  Self-Managed Certificates: %t", c.SelfManagedCerts)
+	if c.SelfManagedCerts {
+		klog.Infof("    Secret: %s/%s, Service: %s, Webhook Configuration: %s",
+			c.Namespace, c.CertSecretName, c.WebhookServiceName, c.WebhookConfigName)
+	}
+	klog.Infof("  Metrics Port: %d", c.MetricsPort)
 	klog.Infof("  Insecure Skip TLS Verify: %t", c.InsecureSkipTLSVerify)
+	klog.Infof("  Node Scope: selector=%q, exclude taints %v, exclude annotations %v",
+		c.NodeSelector, c.NodeExcludeTaints, c.NodeExcludeAnnotations)
+	klog.Infof("  Watcher Workers: %d", c.Workers)
+	klog.Infof("  Dry Run: %t", c.DryRun)
+	klog.Infof("  Cleanup Deadline: warn after %v, alert after %v, force release after %v (0 = disabled)",
+		c.CleanupWarnAfter, c.CleanupAlertAfter, c.CleanupForceReleaseAfter)
+	klog.Infof("  NodeCleanup Retention: %v (0 = keep forever)", c.NodeCleanupRetention)
+	klog.Infof("  Approval: node selector %q, timeout %v (0 = none), on timeout %s",
+		c.ApprovalNodeSelector, c.ApprovalTimeout, c.ApprovalTimeoutAction)
+	klog.Infof("  Maintenance: windows %q, timezone %s, blackout dates %v",
+		c.MaintenanceWindows, c.MaintenanceTimezone, c.MaintenanceBlackoutDates)
+	klog.Infof("  Circuit Breaker: max deletions %d or %d%% within %v, max cleanups per hour %d (0 = no limit), state %s/%s",
+		c.BreakerMaxDeletions, c.BreakerMaxDeletionsPercent, c.BreakerWindow, c.BreakerMaxCleanupsPerHour,
+		c.Namespace, c.BreakerConfigMapName)
+	klog.Infof("  Audit Log: %q (empty = disabled), rotated at %dMB, %d backups",
+		c.AuditLog, c.AuditLogMaxSizeMB, c.AuditLogMaxBackups)
+	klog.Infof("  Leader Election: %t", c.LeaderElect)
+	if c.LeaderElect {
+		klog.Infof("    Lease: %s/%s", c.LeaderElectionNamespace, c.LeaderElectionID)
+		klog.Infof("    Lease Duration: %v, Renew Deadline: %v, Retry Period: %v", c.LeaseDuration, c.RenewDeadline, c.RetryPeriod)
+	}
 	klog.Infof("  Enabled Plugins: %v", c.EnabledPlugins)
+	klog.Infof("  Plugin Timeout: %v (0 = none)", c.PluginTimeout)
 
 	for _, pluginName := range c.EnabledPlugins {
 		if cfg, ok := c.PluginConfigs[pluginName]; ok {
@@ -157,6 +399,55 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationVal, err := time.ParseDuration(value); err == nil {
+			return durationVal
+		}
+		klog.Warningf("Invalid duration for %s: %s, using default %v", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	return getEnvListSep(key, ",")
+}
+
+// getEnvListSep splits a variable on sep, dropping empty entries
+func getEnvListSep(key, sep string) []string {
+	var list []string
+	for _, entry := range strings.Split(os.Getenv(key), sep) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// getEnvFailurePolicy reads an admission failure policy, "Ignore" by default
+func getEnvFailurePolicy(key string) string {
+	switch value := getEnv(key, "Ignore"); value {
+	case "Ignore", "Fail":
+		return value
+	default:
+		klog.Warningf("Invalid failure policy for %s: %s, using Ignore", key, value)
+		return "Ignore"
+	}
+}
+
+// getEnvApprovalTimeoutAction reads what happens when approval times out,
+// "escalate" by default
+func getEnvApprovalTimeoutAction(key string) string {
+	switch value := getEnv(key, constants.ApprovalTimeoutEscalate); value {
+	case constants.ApprovalTimeoutEscalate, constants.ApprovalTimeoutProceed:
+		return value
+	default:
+		klog.Warningf("Invalid approval timeout action for %s: %s, using %s", key, value, constants.ApprovalTimeoutEscalate)
+		return constants.ApprovalTimeoutEscalate
+	}
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
@@ -172,17 +463,91 @@ func getEnvBool(key string, defaultValue bool) bool {
 // PORT=8443
 // TLS_CERT_FILE=/etc/webhook/certs/tls.crt
 // TLS_KEY_FILE=/etc/webhook/certs/tls.key
+// METRICS_PORT=8080  # Plain HTTP /metrics, 0 disables
+// MUTATE_FAILURE_POLICY=Ignore    # Answer when the handler fails internally: Ignore allows, Fail denies
+// VALIDATE_FAILURE_POLICY=Ignore  # Keep in line with the webhook configurations' failurePolicy
+//
+// # Self-managed certificates (instead of cert-manager): the files above are
+// # written from the secret, which must be on a writable volume
+// SELF_MANAGED_CERTS=false
+// CERT_SECRET_NAME=node-cleanup-webhook-tls
+// WEBHOOK_SERVICE_NAME=node-cleanup-webhook
+// WEBHOOK_CONFIG_NAME=node-cleanup-webhook
+//
+// # Only this service account may remove the cleanup finalizer (POD_NAMESPACE
+// # is its namespace); others need infra.894.io/allow-finalizer-removal=<reason>
+// SERVICE_ACCOUNT_NAME=node-cleanup-webhook
 //
 // # Kubernetes client configuration
 // INSECURE_SKIP_TLS_VERIFY=false  # Set to true for insecure kube-apiserver (not recommended for production)
 //
+// # Node scope: only matching nodes get the finalizer (empty = all nodes)
+// NODE_SELECTOR=node-role.kubernetes.io/worker
+// NODE_EXCLUDE_TAINTS=node-role.kubernetes.io/control-plane  # "key" or "key=value", comma-separated
+// NODE_EXCLUDE_ANNOTATIONS=cluster-autoscaler.kubernetes.io/scale-down-disabled=true
+//
+// # Watcher configuration
+// WATCHER_WORKERS=4  # Nodes cleaned up in parallel
+// DRY_RUN=false      # Only plan cleanups (also per node: infra.894.io/dry-run=true)
+//
+// # Cleanup deadline tiers from DeletionTimestamp (0 disables a tier)
+// CLEANUP_WARN_AFTER=15m           # Warning event
+// CLEANUP_ALERT_AFTER=1h           # Warning event + infra.894.io/cleanup-overdue annotation
+// CLEANUP_FORCE_RELEASE_AFTER=0    # Remove the finalizer without completing cleanup
+//
+// NODECLEANUP_RETENTION=24h  # Delete NodeCleanup records of deleted nodes after this (0 keeps them)
+//
+// # Manual approval: matching nodes wait for infra.894.io/cleanup-approved-by=<user>
+// # or POST /approve-cleanup?node=<name> before cleanup runs
+// APPROVAL_NODE_SELECTOR=storage.894.io/tier=critical  # Empty = no approval by label
+// APPROVAL_TIMEOUT=0               # 0 waits forever
+// APPROVAL_TIMEOUT_ACTION=escalate # escalate (warn, keep waiting) or proceed
+//
+// # Maintenance schedule: cleanup only starts inside a window ("<cron> <duration>",
+// # ";"-separated) and not on a blackout date; no windows = any time
+// MAINTENANCE_WINDOWS=0 22 * * MON-FRI 6h;0 8 * * SAT 12h
+// MAINTENANCE_TIMEZONE=Europe/Berlin
+// MAINTENANCE_BLACKOUT_DATES=2026-12-24..2027-01-01,2027-03-31
+//
+// # Circuit breaker: pause new cleanups after a burst of deletions (0 = no limit)
+// # until reset with infra.894.io/reset-breaker=<user> on the ConfigMap or POST /reset-breaker
+// BREAKER_MAX_DELETIONS=10          # Nodes entering deletion within BREAKER_WINDOW
+// BREAKER_MAX_DELETIONS_PERCENT=20  # Of the nodes with the finalizer, within BREAKER_WINDOW
+// BREAKER_WINDOW=10m
+// BREAKER_MAX_CLEANUPS_PER_HOUR=30
+// BREAKER_CONFIGMAP=node-cleanup-webhook-breaker  # In POD_NAMESPACE
+//
+// # Audit log: one JSON record per cleanup decision ("" = disabled)
+// AUDIT_LOG=/var/log/node-cleanup/audit.log  # Or "stdout", apart from klog on stderr
+// AUDIT_LOG_MAX_SIZE_MB=100  # Rotate at this size, 0 never rotates
+// AUDIT_LOG_MAX_BACKUPS=5    # Rotated files kept as audit.log.1 (newest) ... .5
+//
+// # Leader election (only the leader runs the watcher; all replicas serve the webhook)
+// LEADER_ELECT=true
+// LEADER_ELECTION_ID=node-cleanup-webhook
+// LEADER_ELECTION_NAMESPACE=node-cleanup-system  # Defaults to POD_NAMESPACE
+// LEADER_ELECTION_LEASE_DURATION=15s
+// LEADER_ELECTION_RENEW_DEADLINE=10s
+// LEADER_ELECTION_RETRY_PERIOD=2s
+//
 // # Plugin configuration
-// ENABLED_PLUGINS=logger,drain,portworx,slack
+// ENABLED_PLUGINS=logger,drain,portworx,slack  # Run one after another in this order
+// PORTWORX_AFTER=none  # <PLUGIN>_AFTER: comma separated plugins to wait for instead, "none" starts at once
+// PLUGIN_TIMEOUT=5m  # Default per-plugin Cleanup timeout, <PLUGIN>_TIMEOUT overrides it
+// <PLUGIN>_REQUIRE_APPROVAL=false  # The plugin's nodes wait for manual approval
+//
+// # Logger plugin
+// LOGGER_FORMAT=pretty    # pretty, json or logfmt
+// LOGGER_VERBOSITY=info   # minimal, info or debug
+// LOGGER_OUTPUT=stdout    # stdout or stderr; defaults to stderr with AUDIT_LOG=stdout
+// LOGGER_DELAY=15s        # Demo only: hold the cleanup after logging
 //
 // # Portworx plugin
 // PORTWORX_LABEL_SELECTOR=px/enabled=true
 // PORTWORX_API_ENDPOINT=http://portworx-api:9001
-// PORTWORX_TIMEOUT=300s
+// PORTWORX_TIMEOUT=300s  # Defaults to PLUGIN_TIMEOUT
+// PORTWORX_MIN_NODES=3  # Refuse deletions that would leave fewer Portworx nodes
+// PORTWORX_REQUIRE_APPROVAL=false  # Portworx nodes wait for manual approval
 //
 // # Drain plugin
 // DRAIN_TIMEOUT=300s
@@ -6,18 +6,106 @@ import "time"
 const (
 	FinalizerName         = "infra.894.io/node-cleanup"
 	SkipCleanupAnnotation = "infra.894.io/skip-cleanup"
+
+	// RetryCleanupAnnotation re-arms a node whose cleanup exhausted its retries.
+	// Any change to its value triggers a fresh round of attempts.
+	RetryCleanupAnnotation = "infra.894.io/retry-cleanup"
+
+	// CleanupProgressAnnotation records which plugins completed for the node UID
+	CleanupProgressAnnotation = "infra.894.io/cleanup-progress"
+
+	// Per-node overrides of the cleanup deadline tiers (Go durations, "0" disables the tier)
+	CleanupWarnAfterAnnotation         = "infra.894.io/cleanup-warn-after"
+	CleanupAlertAfterAnnotation        = "infra.894.io/cleanup-alert-after"
+	CleanupForceReleaseAfterAnnotation = "infra.894.io/cleanup-force-release-after"
+
+	// SkipDeleteValidationAnnotation ("true") lets a node be deleted even when a
+	// plugin's pre-flight check objects. For emergencies only.
+	SkipDeleteValidationAnnotation = "infra.894.io/skip-delete-validation"
+
+	// CleanupOverdueAnnotation is set on a node whose cleanup passed the alert
+	// tier; the value is the time it was flagged
+	CleanupOverdueAnnotation = "infra.894.io/cleanup-overdue"
+
+	// DryRunAnnotation ("true") makes the watcher only plan the node's cleanup:
+	// no plugin Cleanup runs and its finalizer is never changed
+	DryRunAnnotation = "infra.894.io/dry-run"
+
+	// AllowFinalizerRemovalAnnotation lets users other than the controller
+	// remove the cleanup finalizer. The value must state the reason.
+	AllowFinalizerRemovalAnnotation = "infra.894.io/allow-finalizer-removal"
+
+	// CleanupApprovedByAnnotation approves the cleanup of a node that requires
+	// manual approval. Its value must be the approving user's name.
+	CleanupApprovedByAnnotation = "infra.894.io/cleanup-approved-by"
+
+	// ResetBreakerAnnotation on the circuit breaker ConfigMap resets the
+	// breaker; the value records who reset it
+	ResetBreakerAnnotation = "infra.894.io/reset-breaker"
 )
 
-// Timeouts and durations
+// Actions taken when a cleanup waited APPROVAL_TIMEOUT without approval
 const (
-	// POC demonstration delay
-	POCCleanupDelay = 15 * time.Second
+	ApprovalTimeoutEscalate = "escalate" // Warn and keep waiting
+	ApprovalTimeoutProceed  = "proceed"  // Run the cleanup unapproved
+)
+
+// Approval API: RBAC resource an approver needs "update" on, per node name
+const (
+	ApprovalPath        = "/approve-cleanup"
+	ApprovalGroup       = "infra.894.io"
+	ApprovalResource    = "nodecleanups"
+	ApprovalSubresource = "approval"
+)
+
+// ValidateNodePath serves the node validation webhook; on UPDATE it also makes
+// approvals name the approving user
+const ValidateNodePath = "/validate-node"
 
-	// Retry configuration
+// Circuit breaker: state ConfigMap and reset API (update on nodecleanups/breaker)
+const (
+	DefaultBreakerConfigMapName = "node-cleanup-webhook-breaker"
+	DefaultBreakerWindow        = 10 * time.Minute
+	BreakerResetPath            = "/reset-breaker"
+	BreakerSubresource          = "breaker"
+
+	// How often the watcher checks the ConfigMap for a reset
+	BreakerSyncInterval = 10 * time.Second
+)
+
+// Timeouts and durations
+const (
+	// Retry configuration (per-node exponential backoff starting at DefaultRetryDelay)
 	DefaultRetryDelay     = 10 * time.Second
 	MaxRetryAttempts      = 5
 	ExponentialBackoffMax = 5 * time.Minute
 
+	// Cleanup deadline tiers, measured from the node's DeletionTimestamp.
+	// Forced finalizer release is disabled unless configured.
+	DefaultCleanupWarnAfter         = 15 * time.Minute
+	DefaultCleanupAlertAfter        = 1 * time.Hour
+	DefaultCleanupForceReleaseAfter = 0
+
+	// NodeCleanup records of deleted nodes are deleted once their last
+	// activity is older than the retention, checked every prune interval
+	DefaultNodeCleanupRetention = 24 * time.Hour
+	NodeCleanupPruneInterval    = 10 * time.Minute
+
+	// Default timeout of a single plugin's Cleanup, overridable per plugin
+	// with its "timeout" option (<PLUGIN>_TIMEOUT)
+	DefaultPluginTimeout = 5 * time.Minute
+
+	// How long a plugin past its timeout has to return before it is abandoned
+	PluginAbandonGrace = 1 * time.Second
+
+	// Upper bound for plugin pre-flight checks during DELETE admission; must
+	// stay below the webhook timeoutSeconds
+	ValidationTimeout = 8 * time.Second
+
+	// How often a cleanup deferred by the maintenance schedule is rechecked
+	// when no window opens within a year
+	MaintenanceRecheckInterval = 1 * time.Hour
+
 	// Finalizer operations
 	FinalizerOperationTimeout = 30 * time.Second
 
@@ -26,22 +114,120 @@ const (
 	DefaultHTTPWriteTimeout = 10 * time.Second
 	DefaultShutdownTimeout  = 30 * time.Second
 
+	// How often the serving certificate files are checked for rotation
+	CertificateReloadInterval = 10 * time.Second
+
+	// Upper bound for an AdmissionReview body: a node object and its old
+	// version of up to 3MiB each plus the envelope
+	MaxAdmissionReviewBytes = 7 * 1024 * 1024
+
+	// Upper bound for all /readyz checks together; must stay below the
+	// readiness probe timeoutSeconds
+	ReadinessCheckTimeout = 3 * time.Second
+
+	// Metrics server (plain HTTP, separate from the TLS webhook port)
+	DefaultMetricsPort = 8080
+
 	// Informer and queue configuration
 	DefaultInformerResyncPeriod = 30 * time.Second
-	DefaultWorkQueueSize        = 100
 	InformerCacheSyncTimeout    = 60 * time.Second
 )
 
+// Audit log rotation defaults
+const (
+	DefaultAuditLogMaxSizeMB  = 100
+	DefaultAuditLogMaxBackups = 5
+)
+
+// Work queue configuration
+const (
+	WorkQueueName      = "node-cleanup"
+	DefaultWorkerCount = 4
+)
+
+// Leader election configuration
+const (
+	DefaultNamespace        = "node-cleanup-system"
+	DefaultLeaderElectionID = "node-cleanup-webhook"
+	DefaultLeaseDuration    = 15 * time.Second
+	DefaultRenewDeadline    = 10 * time.Second
+	DefaultRetryPeriod      = 2 * time.Second
+)
+
+// Default name of the Mutating/ValidatingWebhookConfiguration
+const DefaultWebhookConfigName = "node-cleanup-webhook"
+
+// Default in-cluster identity and self-managed certificates (used instead of cert-manager)
+const (
+	DefaultServiceAccountName = "node-cleanup-webhook"
+	DefaultWebhookServiceName = "node-cleanup-webhook"
+	DefaultCertSecretName     = "node-cleanup-webhook-tls"
+
+	// Each is renewed when less than a third of its lifetime remains
+	SelfManagedCAValidity   = 5 * 365 * 24 * time.Hour
+	SelfManagedCertValidity = 365 * 24 * time.Hour
+
+	// How often each replica syncs the Secret, its files and the caBundle
+	SelfManagedCertSyncInterval = 1 * time.Minute
+)
+
+// Event reasons recorded against the Node
+const (
+	EventComponent = "node-cleanup-webhook"
+
+	ReasonFinalizerAdded          = "FinalizerAdded"
+	ReasonFinalizerRemoved        = "FinalizerRemoved"
+	ReasonCleanupStarted          = "CleanupStarted"
+	ReasonCleanupSkipped          = "CleanupSkipped"
+	ReasonCleanupPlanned          = "CleanupPlanned"
+	ReasonCleanupRetryScheduled   = "CleanupRetryScheduled"
+	ReasonCleanupFailed           = "CleanupFailed"
+	ReasonCleanupDeadlineWarning  = "CleanupDeadlineWarning"
+	ReasonCleanupOverdue          = "CleanupOverdue"
+	ReasonCleanupAwaitingApproval = "CleanupAwaitingApproval"
+	ReasonCleanupApproved         = "CleanupApproved"
+	ReasonCleanupApprovalOverdue  = "CleanupApprovalOverdue"
+	ReasonCleanupDeferred         = "CleanupDeferred"
+	ReasonCleanupPaused           = "CleanupPaused"
+	ReasonFinalizerForceReleased  = "FinalizerForceReleased"
+	ReasonPluginStarted           = "PluginStarted"
+	ReasonPluginSucceeded         = "PluginSucceeded"
+	ReasonPluginFailed            = "PluginFailed"
+	ReasonPluginTimedOut          = "PluginTimedOut"
+	ReasonPluginSkipped           = "PluginSkipped"
+	ReasonPluginRolledBack        = "PluginRolledBack"
+	ReasonPluginRollbackFailed    = "PluginRollbackFailed"
+
+	ReasonPortworxDecommissioned = "PortworxDecommissioned"
+)
+
 // Plugin names
 const (
 	LoggerPluginName   = "logger"
 	PortworxPluginName = "portworx"
 )
 
+// Logger plugin output (LOGGER_FORMAT, LOGGER_VERBOSITY)
+const (
+	LoggerFormatPretty = "pretty" // Human-readable report
+	LoggerFormatJSON   = "json"   // One JSON object per node
+	LoggerFormatLogfmt = "logfmt" // One key=value line per node
+
+	LoggerVerbosityMinimal = "minimal" // Name, UID and timestamps only
+	LoggerVerbosityInfo    = "info"    // Full snapshot
+	LoggerVerbosityDebug   = "debug"   // Also allocatable, system info and condition messages
+
+	DefaultLoggerFormat    = LoggerFormatPretty
+	DefaultLoggerVerbosity = LoggerVerbosityInfo
+)
+
 // Portworx labels
 const (
-	PortworxEnabledLabel      = "px/enabled"
-	PortworxStatusLabel       = "px/status"
-	PortworxEnabledValue      = "true"
+	PortworxEnabledLabel         = "px/enabled"
+	PortworxStatusLabel          = "px/status"
+	PortworxEnabledValue         = "true"
 	DefaultPortworxLabelSelector = "px/enabled=true"
+
+	// Portworx nodes that must remain after a deletion to keep quorum
+	DefaultPortworxMinNodes = 3
 )
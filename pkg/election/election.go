@@ -0,0 +1,121 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/894/node-cleanup-webhook/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// Run blocks until ctx is cancelled, calling run while this replica holds the
+// leader lease. run must return once its context is cancelled.
+//
+// On shutdown the lease is released only after run has returned, so the next
+// leader never overlaps with cleanups that are still in flight here.
+func Run(ctx context.Context, client kubernetes.Interface, cfg *config.Config, run func(ctx context.Context)) error {
+	identity, err := identity()
+	if err != nil {
+		return err
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      cfg.LeaderElectionID,
+			Namespace: cfg.LeaderElectionNamespace,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	// The elector gets its own context so the lease outlives ctx until run has drained
+	electorCtx, electorCancel := context.WithCancel(context.Background())
+	defer electorCancel()
+
+	started := make(chan struct{})
+	runDone := make(chan struct{})
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            cfg.LeaderElectionID,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				close(started)
+				defer close(runDone)
+
+				klog.InfoS("Acquired leadership - starting cleanup watcher", "identity", identity)
+
+				// Stop on shutdown or when leadership is lost, whichever comes first
+				runCtx, runCancel := context.WithCancel(ctx)
+				defer runCancel()
+				go func() {
+					select {
+					case <-leaderCtx.Done():
+						runCancel()
+					case <-runCtx.Done():
+					}
+				}()
+
+				run(runCtx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() == nil {
+					// Our informer and queue state cannot be trusted after losing the lease
+					klog.Fatalf("Leadership lost unexpectedly - exiting (identity %s)", identity)
+				}
+				klog.InfoS("Leadership released", "identity", identity)
+			},
+			OnNewLeader: func(current string) {
+				if current != identity {
+					klog.InfoS("Following leader", "leader", current)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create leader elector: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		select {
+		case <-started:
+			<-runDone
+		default:
+		}
+		electorCancel()
+	}()
+
+	klog.InfoS("Starting leader election",
+		"lease", cfg.LeaderElectionNamespace+"/"+cfg.LeaderElectionID,
+		"identity", identity,
+		"leaseDuration", cfg.LeaseDuration,
+		"renewDeadline", cfg.RenewDeadline,
+		"retryPeriod", cfg.RetryPeriod)
+
+	elector.Run(electorCtx)
+	return nil
+}
+
+// identity returns a unique holder identity for this replica
+func identity() (string, error) {
+	if name := os.Getenv("POD_NAME"); name != "" {
+		return name, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine leader election identity: %w", err)
+	}
+	return hostname, nil
+}
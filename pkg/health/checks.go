@@ -0,0 +1,22 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/certs"
+)
+
+// CertificateCheck verifies that the certificate returned by served, the one
+// the webhook currently presents, is valid and reports its expiry
+func CertificateCheck(served func() *x509.Certificate) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		cert := served()
+		if err := certs.CheckValidity(cert, time.Now()); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("expires %s", cert.NotAfter.UTC().Format(time.RFC3339)), nil
+	}
+}
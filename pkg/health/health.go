@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// CheckFunc reports whether one component is ready. detail is shown in
+// verbose output and may be empty.
+type CheckFunc func(ctx context.Context) (detail string, err error)
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker serves a readiness endpoint that runs every registered check.
+// Checks must be added before it serves requests.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker creates a Checker whose checks share the given timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a named check
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// ServeHTTP runs all checks. It responds 200 "ok" when all pass and 503 with
+// every check's status otherwise. With ?verbose the status of every check is
+// listed on success too.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+	defer cancel()
+
+	var out strings.Builder
+	failed := false
+	for _, chk := range c.checks {
+		detail, err := chk.fn(ctx)
+		switch {
+		case err != nil:
+			failed = true
+			klog.V(2).InfoS("Readiness check failed", "check", chk.name, "reason", err.Error())
+			fmt.Fprintf(&out, "[-]%s failed: %v\n", chk.name, err)
+		case detail != "":
+			fmt.Fprintf(&out, "[+]%s ok (%s)\n", chk.name, detail)
+		default:
+			fmt.Fprintf(&out, "[+]%s ok\n", chk.name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if failed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, out.String())
+		fmt.Fprint(w, "readyz check failed\n")
+		return
+	}
+
+	if _, verbose := r.URL.Query()["verbose"]; verbose {
+		fmt.Fprint(w, out.String())
+		fmt.Fprint(w, "readyz check passed\n")
+		return
+	}
+	fmt.Fprint(w, "ok")
+}
@@ -0,0 +1,179 @@
+package maintenance
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // DST cases must not depend on the host's zoneinfo
+)
+
+// bits builds a field bitmask from values
+func bits(values ...int) uint64 {
+	var set uint64
+	for _, value := range values {
+		set |= 1 << uint(value)
+	}
+	return set
+}
+
+// span returns the values from low to high in steps of step
+func span(low, high, step int) []int {
+	var values []int
+	for value := low; value <= high; value += step {
+		values = append(values, value)
+	}
+	return values
+}
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		min, max int
+		names    map[string]int
+		want     uint64
+		wantErr  bool
+	}{
+		{name: "wildcard", field: "*", min: 0, max: 59, want: bits(span(0, 59, 1)...)},
+		{name: "single value", field: "30", min: 0, max: 59, want: bits(30)},
+		{name: "list", field: "0,15,45", min: 0, max: 59, want: bits(0, 15, 45)},
+		{name: "range", field: "9-17", min: 0, max: 23, want: bits(span(9, 17, 1)...)},
+		{name: "wildcard step", field: "*/15", min: 0, max: 59, want: bits(0, 15, 30, 45)},
+		{name: "range step", field: "10-30/10", min: 0, max: 59, want: bits(10, 20, 30)},
+		{name: "start step runs to the end", field: "5/20", min: 0, max: 59, want: bits(5, 25, 45)},
+		{name: "step from field minimum", field: "*/2", min: 1, max: 12, want: bits(1, 3, 5, 7, 9, 11)},
+		{name: "list of ranges and steps", field: "1-3,*/30", min: 0, max: 59, want: bits(0, 1, 2, 3, 30)},
+		{name: "month names", field: "JAN,mar-May", min: 1, max: 12, names: monthNames, want: bits(1, 3, 4, 5)},
+		{name: "weekday names", field: "MON-FRI", min: 0, max: 7, names: dayNames, want: bits(1, 2, 3, 4, 5)},
+		{name: "weekday 7", field: "7", min: 0, max: 7, names: dayNames, want: bits(7)},
+		{name: "value above range", field: "60", min: 0, max: 59, wantErr: true},
+		{name: "value below range", field: "0", min: 1, max: 31, wantErr: true},
+		{name: "reversed range", field: "17-9", min: 0, max: 23, wantErr: true},
+		{name: "zero step", field: "*/0", min: 0, max: 59, wantErr: true},
+		{name: "invalid step", field: "*/x", min: 0, max: 59, wantErr: true},
+		{name: "unknown name", field: "FOO", min: 1, max: 12, names: monthNames, wantErr: true},
+		{name: "empty", field: "", min: 0, max: 59, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseField(tt.field, tt.min, tt.max, tt.names)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseField(%q) = %b, want error", tt.field, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseField(%q) error: %v", tt.field, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseField(%q) = %b, want %b", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronSunday(t *testing.T) {
+	tests := []struct {
+		name string
+		dow  string
+		want uint64
+	}{
+		{name: "0 is Sunday", dow: "0", want: bits(0)},
+		{name: "7 is Sunday", dow: "7", want: bits(0, 7)},
+		{name: "SUN", dow: "SUN", want: bits(0)},
+		{name: "range ending in 7", dow: "5-7", want: bits(0, 5, 6, 7)},
+	}
+
+	sunday := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseCron([]string{"0", "12", "*", "*", tt.dow})
+			if err != nil {
+				t.Fatalf("parseCron error: %v", err)
+			}
+			if spec.dow != tt.want {
+				t.Errorf("dow = %b, want %b", spec.dow, tt.want)
+			}
+			if !spec.dayMatches(sunday) {
+				t.Errorf("%q does not match Sunday %s", tt.dow, sunday.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		location string
+		from     string
+		want     string // empty: no firing within the limit
+	}{
+		{name: "rounds up to the minute", spec: "* * * * *", location: "UTC",
+			from: "2026-10-16 10:00:30", want: "2026-10-16 10:01:00"},
+		{name: "exact match", spec: "0 22 * * *", location: "UTC",
+			from: "2026-10-16 22:00:00", want: "2026-10-16 22:00:00"},
+		{name: "next weekday", spec: "0 22 * * MON-FRI", location: "UTC",
+			from: "2026-10-16 22:01:00", want: "2026-10-19 22:00:00"},
+		{name: "day of month or weekday", spec: "0 0 1 * SUN", location: "UTC",
+			from: "2026-10-16 00:00:00", want: "2026-10-18 00:00:00"},
+		{name: "impossible date", spec: "0 0 30 2 *", location: "UTC",
+			from: "2026-01-01 00:00:00"},
+		// Europe/Berlin skips 02:00-03:00 on 2026-03-29 and repeats it on 2026-10-25
+		{name: "DST gap skips the day", spec: "30 2 * * *", location: "Europe/Berlin",
+			from: "2026-03-29 00:00:00", want: "2026-03-30 02:30:00 +0200"},
+		{name: "DST overlap first pass", spec: "30 2 * * *", location: "Europe/Berlin",
+			from: "2026-10-25 00:00:00", want: "2026-10-25 02:30:00 +0200"},
+		{name: "DST overlap second pass", spec: "30 2 * * *", location: "Europe/Berlin",
+			from: "2026-10-25 02:31:00 +0200", want: "2026-10-25 02:30:00 +0100"},
+		// America/Santiago skips midnight on 2026-09-06
+		{name: "DST gap at midnight", spec: "0 12 6 9 *", location: "America/Santiago",
+			from: "2026-09-05 23:30:00", want: "2026-09-06 12:00:00"},
+		{name: "skipped midnight does not fire", spec: "0 0 * * *", location: "America/Santiago",
+			from: "2026-09-05 12:00:00", want: "2026-09-07 00:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := time.LoadLocation(tt.location)
+			if err != nil {
+				t.Fatalf("LoadLocation: %v", err)
+			}
+			spec, err := parseCron(strings.Fields(tt.spec))
+			if err != nil {
+				t.Fatalf("parseCron(%q) error: %v", tt.spec, err)
+			}
+			from := parseTime(t, tt.from, location)
+
+			got, ok := spec.next(from, from.Add(7*24*time.Hour))
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("next(%s) = %s, want none", from, got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("next(%s) found nothing, want %s", from, tt.want)
+			}
+			if want := parseTime(t, tt.want, location); !got.Equal(want) {
+				t.Errorf("next(%s) = %s, want %s", from, got, want)
+			}
+		})
+	}
+}
+
+// parseTime parses "2006-01-02 15:04:05" in location, with an optional UTC
+// offset to pick one side of a DST overlap
+func parseTime(t *testing.T, value string, location *time.Location) time.Time {
+	t.Helper()
+	if parsed, err := time.Parse("2006-01-02 15:04:05 -0700", value); err == nil {
+		return parsed.In(location)
+	}
+	parsed, err := time.ParseInLocation(time.DateTime, value, location)
+	if err != nil {
+		t.Fatalf("invalid time %q: %v", value, err)
+	}
+	return parsed
+}
@@ -0,0 +1,194 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How far ahead NextOpen looks for a window
+const searchHorizon = 366 * 24 * time.Hour
+
+// Schedule decides when destructive cleanup may start: inside one of the
+// maintenance windows and not on a blackout date, both in the schedule's time
+// zone. Without windows every time outside the blackout dates is allowed.
+type Schedule struct {
+	windows   []window
+	blackouts []dateRange
+	location  *time.Location
+}
+
+// window opens whenever its cron spec fires and stays open for duration
+type window struct {
+	spec     *cronSpec
+	duration time.Duration
+	raw      string
+}
+
+// dateRange is an inclusive range of calendar days, as yyyymmdd numbers
+type dateRange struct {
+	first, last int
+	raw         string
+}
+
+// New parses the maintenance configuration. Each window is a 5-field cron
+// expression followed by how long the window stays open, e.g.
+// "0 22 * * MON-FRI 6h". Blackout dates are "2006-01-02" or
+// "2006-01-02..2006-01-05". An empty timezone means UTC.
+func New(windows []string, timezone string, blackoutDates []string) (*Schedule, error) {
+	location := time.UTC
+	if timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid maintenance timezone %q: %w", timezone, err)
+		}
+	}
+	schedule := &Schedule{location: location}
+
+	for _, raw := range windows {
+		fields := strings.Fields(raw)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("invalid maintenance window %q: expected \"<minute> <hour> <day> <month> <weekday> <duration>\"", raw)
+		}
+		spec, err := parseCron(fields[:5])
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %w", raw, err)
+		}
+		duration, err := time.ParseDuration(fields[5])
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid maintenance window %q: invalid duration %q", raw, fields[5])
+		}
+		schedule.windows = append(schedule.windows, window{spec: spec, duration: duration, raw: raw})
+	}
+
+	for _, raw := range blackoutDates {
+		from, to, isRange := strings.Cut(raw, "..")
+		first, err := parseDate(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blackout date %q: %w", raw, err)
+		}
+		last := first
+		if isRange {
+			if last, err = parseDate(to); err != nil {
+				return nil, fmt.Errorf("invalid blackout date %q: %w", raw, err)
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid blackout date %q: range ends before it starts", raw)
+			}
+		}
+		schedule.blackouts = append(schedule.blackouts, dateRange{first: first, last: last, raw: raw})
+	}
+
+	return schedule, nil
+}
+
+func parseDate(value string) (int, error) {
+	parsed, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return dayNumber(parsed), nil
+}
+
+// dayNumber encodes t's calendar day as yyyymmdd, comparable across ranges
+func dayNumber(t time.Time) int {
+	year, month, day := t.Date()
+	return year*10000 + int(month)*100 + day
+}
+
+// Enabled reports whether any window or blackout date restricts cleanup
+func (s *Schedule) Enabled() bool {
+	return len(s.windows) > 0 || len(s.blackouts) > 0
+}
+
+// Open reports whether cleanup may start at now
+func (s *Schedule) Open(now time.Time) bool {
+	return s.Reason(now) == ""
+}
+
+// Reason explains why cleanup may not start at now, or returns "" if it may
+func (s *Schedule) Reason(now time.Time) string {
+	now = now.In(s.location)
+	if blackout := s.blackout(now); blackout != nil {
+		return fmt.Sprintf("blackout date %s", blackout.raw)
+	}
+	if !s.inWindow(now) {
+		return "outside maintenance windows"
+	}
+	return ""
+}
+
+// NextOpen returns when cleanup may next start, at or after now. It returns
+// false if that is not within a year.
+func (s *Schedule) NextOpen(now time.Time) (time.Time, bool) {
+	t := now.In(s.location)
+	limit := t.Add(searchHorizon)
+
+	for !t.After(limit) {
+		if blackout := s.blackout(t); blackout != nil {
+			// Skip to the start of the day after the blackout
+			lastDay := blackout.last
+			t = time.Date(lastDay/10000, time.Month(lastDay/100%100), lastDay%100+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.inWindow(t) {
+			return t, true
+		}
+
+		// Jump to the earliest window opening after t
+		var earliest time.Time
+		for _, w := range s.windows {
+			if opens, ok := w.spec.next(t, limit); ok && (earliest.IsZero() || opens.Before(earliest)) {
+				earliest = opens
+			}
+		}
+		if earliest.IsZero() {
+			return time.Time{}, false
+		}
+		t = earliest
+	}
+	return time.Time{}, false
+}
+
+// String describes the schedule for logs
+func (s *Schedule) String() string {
+	if !s.Enabled() {
+		return "always open"
+	}
+	windows := make([]string, 0, len(s.windows))
+	for _, w := range s.windows {
+		windows = append(windows, fmt.Sprintf("%q", w.raw))
+	}
+	blackouts := make([]string, 0, len(s.blackouts))
+	for _, b := range s.blackouts {
+		blackouts = append(blackouts, b.raw)
+	}
+	return fmt.Sprintf("windows [%s], blackout dates [%s], timezone %s",
+		strings.Join(windows, ", "), strings.Join(blackouts, ", "), s.location)
+}
+
+// blackout returns the blackout range covering t's day, if any
+func (s *Schedule) blackout(t time.Time) *dateRange {
+	day := dayNumber(t)
+	for i := range s.blackouts {
+		if day >= s.blackouts[i].first && day <= s.blackouts[i].last {
+			return &s.blackouts[i]
+		}
+	}
+	return nil
+}
+
+// inWindow reports whether t falls inside a window: some window opened at or
+// before t and less than its duration ago
+func (s *Schedule) inWindow(t time.Time) bool {
+	if len(s.windows) == 0 {
+		return true
+	}
+	for _, w := range s.windows {
+		// The first opening after t-duration; windows open on whole minutes
+		if opened, ok := w.spec.next(t.Add(-w.duration).Add(time.Nanosecond), t); ok && !opened.After(t) {
+			return true
+		}
+	}
+	return false
+}
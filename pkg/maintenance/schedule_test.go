@@ -0,0 +1,112 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInWindow(t *testing.T) {
+	// Opens at 22:00 on weekdays for 6h, so Friday's window runs into Saturday
+	schedule, err := New([]string{"0 22 * * MON-FRI 6h"}, "", nil)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   string
+		want bool
+	}{
+		{name: "before opening", at: "2026-10-16 21:59:59", want: false},
+		{name: "at opening", at: "2026-10-16 22:00:00", want: true},
+		{name: "past midnight", at: "2026-10-17 00:30:00", want: true},
+		{name: "last instant", at: "2026-10-17 03:59:59", want: true},
+		{name: "at closing", at: "2026-10-17 04:00:00", want: false},
+		{name: "weekday outside the spec", at: "2026-10-17 22:00:00", want: false},
+		{name: "next opening", at: "2026-10-19 22:00:00", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := parseTime(t, tt.at, time.UTC)
+			if got := schedule.inWindow(at); got != tt.want {
+				t.Errorf("inWindow(%s) = %t, want %t", at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInWindowWithoutWindows(t *testing.T) {
+	schedule, err := New(nil, "", []string{"2026-12-24"})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	if !schedule.inWindow(time.Date(2026, time.October, 16, 3, 0, 0, 0, time.UTC)) {
+		t.Error("inWindow without windows = false, want true")
+	}
+}
+
+func TestNextOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		windows   []string
+		timezone  string
+		blackouts []string
+		from      string
+		want      string // empty: not within the search horizon
+	}{
+		{name: "inside a window", windows: []string{"0 22 * * * 2h"},
+			from: "2026-10-16 23:00:00", want: "2026-10-16 23:00:00"},
+		{name: "before a window", windows: []string{"0 22 * * * 2h"},
+			from: "2026-10-16 12:00:00", want: "2026-10-16 22:00:00"},
+		{name: "earliest of several windows", windows: []string{"0 22 * * * 2h", "0 14 * * * 1h"},
+			from: "2026-10-16 12:00:00", want: "2026-10-16 14:00:00"},
+		{name: "window opening on a blackout date", windows: []string{"0 22 * * * 2h"},
+			blackouts: []string{"2026-12-24..2026-12-26"},
+			from:      "2026-12-24 10:00:00", want: "2026-12-27 22:00:00"},
+		{name: "window running into a blackout date", windows: []string{"0 22 * * * 4h"},
+			blackouts: []string{"2026-12-24"},
+			from:      "2026-12-24 00:30:00", want: "2026-12-25 00:00:00"},
+		{name: "adjacent blackout ranges", windows: []string{"0 22 * * * 2h"},
+			blackouts: []string{"2026-12-24..2026-12-26", "2026-12-27"},
+			from:      "2026-12-24 10:00:00", want: "2026-12-28 22:00:00"},
+		{name: "blackout across the year end", blackouts: []string{"2026-12-30..2027-01-02"},
+			from: "2026-12-31 12:00:00", want: "2027-01-03 00:00:00"},
+		{name: "last blackout day", blackouts: []string{"2026-12-24..2026-12-26"},
+			from: "2026-12-26 23:59:59", want: "2026-12-27 00:00:00"},
+		{name: "blackout in the schedule's time zone", windows: []string{"0 22 * * * 2h"},
+			timezone: "Europe/Berlin", blackouts: []string{"2026-12-24"},
+			from: "2026-12-23 23:30:00", want: "2026-12-25 22:00:00 +0100"},
+		{name: "window never opens", windows: []string{"0 0 30 2 * 1h"},
+			from: "2026-10-16 12:00:00"},
+		{name: "blackout beyond the horizon", blackouts: []string{"2026-10-01..2028-01-01"},
+			from: "2026-10-16 12:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := New(tt.windows, tt.timezone, tt.blackouts)
+			if err != nil {
+				t.Fatalf("New error: %v", err)
+			}
+			from := parseTime(t, tt.from, time.UTC)
+
+			got, ok := schedule.NextOpen(from)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("NextOpen(%s) = %s, want none", from, got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("NextOpen(%s) found nothing, want %s", from, tt.want)
+			}
+			if want := parseTime(t, tt.want, time.UTC); !got.Equal(want) {
+				t.Errorf("NextOpen(%s) = %s, want %s", from, got, want)
+			}
+			if !schedule.Open(got) {
+				t.Errorf("Open(%s) = false at the returned time: %s", got, schedule.Reason(got))
+			}
+		})
+	}
+}
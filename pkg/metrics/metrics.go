@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Plugin and cleanup result label values
+const (
+	ResultSuccess   = "success"
+	ResultFailure   = "failure"
+	ResultTimeout   = "timeout"
+	ResultSkipped   = "skipped"
+	ResultRetry     = "retry"
+	ResultExhausted = "exhausted"
+	ResultDryRun    = "dry_run"
+)
+
+// Circuit breaker trip reason label values
+const (
+	BreakerReasonDeletions = "deletions"
+	BreakerReasonRate      = "rate"
+)
+
+var (
+	// Webhook metrics
+	WebhookRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "node_cleanup_webhook_requests_total",
+			Help: "Total number of webhook admission requests",
+		},
+		[]string{"operation", "result"},
+	)
+
+	WebhookDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "node_cleanup_webhook_duration_seconds",
+			Help:    "Webhook admission request duration in seconds",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 12),
+		},
+		[]string{"operation"},
+	)
+
+	WebhookPanicsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "node_cleanup_webhook_panics_total",
+			Help: "Total number of admission handler panics answered by the failure policy",
+		},
+		[]string{"path"},
+	)
+
+	// Cleanup metrics
+	CleanupAttemptsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "node_cleanup_attempts_total",
+			Help: "Total number of node cleanup attempts by outcome",
+		},
+		[]string{"result"},
+	)
+
+	CleanupEscalationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "node_cleanup_deadline_escalations_total",
+			Help: "Total number of cleanup deadline escalations by tier",
+		},
+		[]string{"tier"},
+	)
+
+	// Circuit breaker metrics
+	BreakerTripped = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "node_cleanup_breaker_tripped",
+			Help: "Whether the circuit breaker is tripped and new cleanups are paused (1) or not (0)",
+		},
+	)
+
+	BreakerTripsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "node_cleanup_breaker_trips_total",
+			Help: "Total number of circuit breaker trips by reason",
+		},
+		[]string{"reason"},
+	)
+
+	// Plugin metrics
+	PluginRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "node_cleanup_plugin_runs_total",
+			Help: "Total number of cleanup plugin runs by outcome",
+		},
+		[]string{"plugin", "result"},
+	)
+
+	PluginRollbacksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "node_cleanup_plugin_rollbacks_total",
+			Help: "Total number of plugin rollbacks after a permanent cleanup failure by outcome",
+		},
+		[]string{"plugin", "result"},
+	)
+
+	PluginDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "node_cleanup_plugin_duration_seconds",
+			Help:    "Cleanup plugin run duration in seconds",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+		},
+		[]string{"plugin"},
+	)
+)
+
+// NodeCounts reports how many nodes carry the cleanup finalizer and how many
+// of those are terminating, i.e. held by it
+type NodeCounts func() (withFinalizer, held int)
+
+var (
+	nodeCountsMu sync.RWMutex
+	nodeCounts   NodeCounts
+)
+
+// SetNodeCounts sets the source of the node gauges. Until it is called, and
+// after it is reset with nil, the gauges report zero.
+func SetNodeCounts(counts NodeCounts) {
+	nodeCountsMu.Lock()
+	defer nodeCountsMu.Unlock()
+	nodeCounts = counts
+}
+
+func currentNodeCounts() (int, int) {
+	nodeCountsMu.RLock()
+	defer nodeCountsMu.RUnlock()
+	if nodeCounts == nil {
+		return 0, 0
+	}
+	return nodeCounts()
+}
+
+var (
+	_ = promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "node_cleanup_nodes_with_finalizer",
+			Help: "Current number of nodes with the cleanup finalizer",
+		},
+		func() float64 {
+			withFinalizer, _ := currentNodeCounts()
+			return float64(withFinalizer)
+		},
+	)
+
+	_ = promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "node_cleanup_nodes_held",
+			Help: "Current number of terminating nodes held by the cleanup finalizer",
+		},
+		func() float64 {
+			_, held := currentNodeCounts()
+			return float64(held)
+		},
+	)
+)
+
+// ObserveWebhook records one admission request
+func ObserveWebhook(operation, result string, start time.Time) {
+	WebhookRequestsTotal.WithLabelValues(operation, result).Inc()
+	WebhookDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// ObservePlugin records one plugin run; a nil err counts as success and a
+// deadline error as timeout
+func ObservePlugin(plugin string, err error, start time.Time) {
+	PluginRunsTotal.WithLabelValues(plugin, resultOf(err)).Inc()
+	PluginDuration.WithLabelValues(plugin).Observe(time.Since(start).Seconds())
+}
+
+// ObserveRollback records one plugin rollback
+func ObserveRollback(plugin string, err error) {
+	PluginRollbacksTotal.WithLabelValues(plugin, resultOf(err)).Inc()
+}
+
+func resultOf(err error) string {
+	switch {
+	case err == nil:
+		return ResultSuccess
+	case errors.Is(err, context.DeadlineExceeded):
+		return ResultTimeout
+	default:
+		return ResultFailure
+	}
+}
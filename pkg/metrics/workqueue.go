@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Work queue metrics, labelled by queue name
+var (
+	queueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_cleanup_workqueue_depth",
+		Help: "Current number of nodes waiting in the work queue",
+	}, []string{"name"})
+
+	queueAdds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "node_cleanup_workqueue_adds_total",
+		Help: "Total number of nodes added to the work queue",
+	}, []string{"name"})
+
+	queueLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "node_cleanup_workqueue_queue_duration_seconds",
+		Help:    "How long a node waits in the work queue before a worker picks it up",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"name"})
+
+	queueWorkDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "node_cleanup_workqueue_work_duration_seconds",
+		Help:    "How long processing a node from the work queue takes",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"name"})
+
+	queueUnfinishedWork = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_cleanup_workqueue_unfinished_work_seconds",
+		Help: "Seconds of work in progress that has not been observed by work_duration",
+	}, []string{"name"})
+
+	queueLongestRunning = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_cleanup_workqueue_longest_running_processor_seconds",
+		Help: "Seconds the longest running cleanup has been in progress",
+	}, []string{"name"})
+
+	queueRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "node_cleanup_workqueue_retries_total",
+		Help: "Total number of cleanup retries scheduled by the work queue",
+	}, []string{"name"})
+)
+
+func init() {
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// workqueueMetricsProvider exposes client-go work queue metrics to Prometheus
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return queueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return queueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return queueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return queueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return queueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return queueLongestRunning.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return queueRetries.WithLabelValues(name)
+}
@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ApprovalRequirer is implemented by plugins whose cleanup must be approved
+// by a human for some nodes, e.g. nodes holding the last replica of a volume
+type ApprovalRequirer interface {
+	// RequiresApproval reports whether cleaning up the node needs approval
+	RequiresApproval(node *corev1.Node) bool
+}
+
+// SetRequiresApproval makes every cleanup the plugin runs wait for manual
+// approval, regardless of ApprovalRequirer
+func (r *Registry) SetRequiresApproval(name string, required bool) {
+	r.requireApproval[name] = required
+}
+
+// ApprovalRequiredBy returns the enabled plugins that would run for the node
+// and require its cleanup to be approved first, in execution order
+func (r *Registry) ApprovalRequiredBy(node *corev1.Node) []string {
+	var names []string
+	for _, name := range r.pluginOrder {
+		plugin, exists := r.plugins[name]
+		if !exists || !plugin.ShouldRun(node) {
+			continue
+		}
+
+		requirer, ok := plugin.(ApprovalRequirer)
+		if r.requireApproval[name] || (ok && requirer.RequiresApproval(node)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// MayRequireApproval reports whether any enabled plugin can require approval,
+// by configuration or through ApprovalRequirer
+func (r *Registry) MayRequireApproval() bool {
+	for _, name := range r.pluginOrder {
+		if r.requireApproval[name] {
+			return true
+		}
+		if _, ok := r.plugins[name].(ApprovalRequirer); ok {
+			return true
+		}
+	}
+	return false
+}
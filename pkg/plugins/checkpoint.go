@@ -0,0 +1,35 @@
+package plugins
+
+import "context"
+
+// Checkpoint records which plugins have already completed for a node so that
+// retries and controller restarts resume at the step that failed instead of
+// re-running destructive plugins
+type Checkpoint interface {
+	// Completed reports whether the plugin already succeeded for this node
+	Completed(name string) bool
+
+	// MarkCompleted persists that the plugin succeeded for this node
+	MarkCompleted(ctx context.Context, name string) error
+
+	// CompletedPlugins returns the completed plugins in completion order
+	CompletedPlugins() []string
+
+	// MarkRolledBack persists that the plugin's cleanup was undone, so it
+	// runs again if cleanup is retried
+	MarkRolledBack(ctx context.Context, name string) error
+}
+
+// AlwaysRunner is implemented by plugins that opt out of checkpointing and
+// must run on every attempt, even after a previous attempt completed them
+type AlwaysRunner interface {
+	AlwaysRun() bool
+}
+
+// alwaysRuns reports whether the plugin opted out of checkpointing
+func alwaysRuns(plugin Plugin) bool {
+	if ar, ok := plugin.(AlwaysRunner); ok {
+		return ar.AlwaysRun()
+	}
+	return false
+}
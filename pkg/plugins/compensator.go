@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/894/node-cleanup-webhook/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// Compensator is implemented by plugins that can undo their cleanup. When a
+// node's cleanup fails permanently, the plugins that already completed are
+// rolled back in reverse completion order.
+type Compensator interface {
+	// Rollback undoes the plugin's completed cleanup. cause is the error that
+	// failed the pipeline.
+	Rollback(ctx context.Context, node *corev1.Node, cause error) error
+}
+
+// RollbackAll rolls back the plugins recorded as completed in the checkpoint,
+// most recently completed first. Plugins that do not implement Compensator
+// are left as they are. A failed rollback is reported and the remaining
+// plugins are still rolled back; all rollback errors are joined.
+func (r *Registry) RollbackAll(ctx context.Context, node *corev1.Node, checkpoint Checkpoint, cause error, observer Observer) error {
+	if observer == nil {
+		observer = nopObserver{}
+	}
+
+	completed := checkpoint.CompletedPlugins()
+	klog.InfoS("Rolling back completed cleanup plugins", "node", node.Name, "completedPlugins", completed, "cause", cause.Error())
+
+	rolledBack := 0
+	var errs []error
+	for i := len(completed) - 1; i >= 0; i-- {
+		name := completed[i]
+		plugin, exists := r.plugins[name]
+		if !exists {
+			klog.ErrorS(nil, "Completed plugin not found in registry - cannot roll back", "plugin", name, "node", node.Name)
+			continue
+		}
+		compensator, ok := plugin.(Compensator)
+		if !ok {
+			klog.V(2).InfoS("Plugin has no rollback", "plugin", name, "node", node.Name)
+			continue
+		}
+
+		klog.InfoS("Rolling back plugin", "plugin", name, "node", node.Name)
+		err := r.runWithTimeout(ctx, plugin, node, func(ctx context.Context) error {
+			return compensator.Rollback(ctx, node, cause)
+		})
+		metrics.ObserveRollback(name, err)
+		if err != nil {
+			klog.ErrorS(err, "Plugin rollback failed", "plugin", name, "node", node.Name)
+			observer.PluginRollbackFailed(ctx, node, name, err)
+			errs = append(errs, fmt.Errorf("plugin %s rollback failed: %w", name, err))
+			continue
+		}
+
+		klog.InfoS("Plugin rolled back", "plugin", name, "node", node.Name)
+		observer.PluginRolledBack(ctx, node, name)
+		rolledBack++
+
+		if err := checkpoint.MarkRolledBack(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s rolled back but checkpoint failed: %w", name, err))
+		}
+	}
+
+	klog.InfoS("Rollback finished", "node", node.Name, "rolledBackPlugins", rolledBack, "failedRollbacks", len(errs))
+	return errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// Dependent is implemented by plugins that must run after other plugins.
+// BasePlugin implements it with the dependencies it was constructed with.
+type Dependent interface {
+	// After returns the names of the plugins that must complete first
+	After() []string
+}
+
+// NoDependencies as the only entry of SetAfter lets a plugin start right
+// away instead of after the plugin enabled before it
+const NoDependencies = "none"
+
+// SetAfter overrides the plugins that must complete before the named plugin,
+// replacing those it declares through Dependent. An empty list or
+// NoDependencies makes it start without waiting.
+func (r *Registry) SetAfter(name string, after []string) {
+	if after == nil || len(after) == 1 && after[0] == NoDependencies {
+		after = []string{}
+	}
+	r.after[name] = after
+	r.graph = nil
+}
+
+// dependenciesOf returns the plugins that must complete before the plugin at
+// position i of the ENABLED_PLUGINS order: those set with SetAfter, else those
+// the plugin declares, else the plugin enabled just before it, so that
+// plugins without dependencies run one after another in ENABLED_PLUGINS order
+func (r *Registry) dependenciesOf(i int) []string {
+	name := r.pluginOrder[i]
+	if after, ok := r.after[name]; ok {
+		return after
+	}
+	if dependent, ok := r.plugins[name].(Dependent); ok && len(dependent.After()) > 0 {
+		return dependent.After()
+	}
+	if i > 0 {
+		return []string{r.pluginOrder[i-1]}
+	}
+	return nil
+}
+
+// graph is the dependency graph of the enabled plugins
+type graph struct {
+	// Enabled dependencies of each enabled plugin
+	deps map[string][]string
+	// Topological order, ties broken by the ENABLED_PLUGINS order
+	order []string
+}
+
+// BuildGraph resolves the dependencies of the enabled plugins and checks them
+// for cycles. Call it once all plugins are enabled; RunAll builds the graph
+// itself if it was not called. Dependencies on registered plugins that are not
+// enabled are ignored; unknown plugins and plugins enabled twice are an error.
+func (r *Registry) BuildGraph() error {
+	g, err := r.buildGraph()
+	if err != nil {
+		return err
+	}
+	r.graph = g
+
+	for _, name := range g.order {
+		if len(g.deps[name]) > 0 {
+			klog.InfoS("Plugin dependencies", "plugin", name, "after", g.deps[name])
+		}
+	}
+	return nil
+}
+
+func (r *Registry) buildGraph() (*graph, error) {
+	g := &graph{deps: make(map[string][]string, len(r.pluginOrder))}
+	position := make(map[string]int, len(r.pluginOrder))
+	for i, name := range r.pluginOrder {
+		if _, duplicate := position[name]; duplicate {
+			return nil, fmt.Errorf("plugin %s is enabled more than once", name)
+		}
+		position[name] = i
+	}
+
+	dependents := make(map[string][]string)
+	pending := make(map[string]int, len(r.pluginOrder))
+	for i, name := range r.pluginOrder {
+		if _, exists := r.plugins[name]; !exists {
+			continue
+		}
+		pending[name] = 0
+
+		for _, dep := range r.dependenciesOf(i) {
+			if _, registered := r.plugins[dep]; !registered {
+				return nil, fmt.Errorf("plugin %s depends on unknown plugin %s", name, dep)
+			}
+			if _, enabled := position[dep]; !enabled {
+				klog.InfoS("Ignoring dependency on disabled plugin", "plugin", name, "dependency", dep)
+				continue
+			}
+			if dep == name {
+				return nil, fmt.Errorf("plugin %s depends on itself", name)
+			}
+			g.deps[name] = append(g.deps[name], dep)
+			dependents[dep] = append(dependents[dep], name)
+			pending[name]++
+		}
+	}
+
+	// Kahn's algorithm, always picking the earliest enabled ready plugin
+	for len(g.order) < len(pending) {
+		next := ""
+		for _, name := range r.pluginOrder {
+			if count, ok := pending[name]; ok && count == 0 {
+				next = name
+				break
+			}
+		}
+		if next == "" {
+			var cycle []string
+			for _, name := range r.pluginOrder {
+				if pending[name] > 0 {
+					cycle = append(cycle, name)
+				}
+			}
+			return nil, fmt.Errorf("plugin dependency cycle involving: %s", strings.Join(cycle, ", "))
+		}
+
+		g.order = append(g.order, next)
+		pending[next] = -1 // placed
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+		}
+	}
+
+	return g, nil
+}
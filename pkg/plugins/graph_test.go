@@ -0,0 +1,190 @@
+package plugins
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// testPlugin is a plugin that declares dependencies and does nothing
+type testPlugin struct {
+	BasePlugin
+}
+
+func (p *testPlugin) ShouldRun(node *corev1.Node) bool { return true }
+
+func (p *testPlugin) Cleanup(ctx context.Context, node *corev1.Node) error { return nil }
+
+func TestBuildGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared map[string][]string // Dependencies declared through Dependent
+		enabled  []string
+		after    map[string][]string // Dependencies set with SetAfter
+		want     []string
+		wantDeps map[string][]string
+		wantErr  string
+	}{
+		{
+			name:     "enabled order by default",
+			enabled:  []string{"a", "b", "c"},
+			want:     []string{"a", "b", "c"},
+			wantDeps: map[string][]string{"b": {"a"}, "c": {"b"}},
+		},
+		{
+			name:     "declared dependencies replace the default",
+			declared: map[string][]string{"c": {"a"}},
+			enabled:  []string{"a", "b", "c"},
+			want:     []string{"a", "b", "c"},
+			wantDeps: map[string][]string{"b": {"a"}, "c": {"a"}},
+		},
+		{
+			name:     "SetAfter replaces declared dependencies",
+			declared: map[string][]string{"c": {"a"}},
+			enabled:  []string{"a", "b", "c"},
+			after:    map[string][]string{"c": {"b"}},
+			want:     []string{"a", "b", "c"},
+			wantDeps: map[string][]string{"b": {"a"}, "c": {"b"}},
+		},
+		{
+			name:     "none starts at once",
+			enabled:  []string{"a", "b", "c"},
+			after:    map[string][]string{"c": {NoDependencies}},
+			want:     []string{"a", "b", "c"},
+			wantDeps: map[string][]string{"b": {"a"}},
+		},
+		{
+			name:     "empty SetAfter starts at once",
+			enabled:  []string{"a", "b"},
+			after:    map[string][]string{"b": nil},
+			want:     []string{"a", "b"},
+			wantDeps: map[string][]string{},
+		},
+		{
+			name:     "dependencies reorder plugins",
+			declared: map[string][]string{"a": {"b"}},
+			enabled:  []string{"a", "b"},
+			after:    map[string][]string{"b": {NoDependencies}},
+			want:     []string{"b", "a"},
+			wantDeps: map[string][]string{"a": {"b"}},
+		},
+		{
+			name:     "ties broken by the enabled order",
+			declared: map[string][]string{"c": {"a"}, "b": {"a"}},
+			enabled:  []string{"a", "c", "b"},
+			want:     []string{"a", "c", "b"},
+			wantDeps: map[string][]string{"b": {"a"}, "c": {"a"}},
+		},
+		{
+			name:     "dependency on a disabled plugin is ignored",
+			declared: map[string][]string{"b": {"d"}},
+			enabled:  []string{"a", "b"},
+			want:     []string{"a", "b"},
+			wantDeps: map[string][]string{},
+		},
+		{
+			name:     "unknown dependency",
+			declared: map[string][]string{"b": {"x"}},
+			enabled:  []string{"a", "b"},
+			wantErr:  "plugin b depends on unknown plugin x",
+		},
+		{
+			name:    "self dependency",
+			enabled: []string{"a", "b"},
+			after:   map[string][]string{"a": {"a"}},
+			wantErr: "plugin a depends on itself",
+		},
+		{
+			name:    "cycle",
+			enabled: []string{"a", "b", "c"},
+			after:   map[string][]string{"a": {"c"}},
+			wantErr: "plugin dependency cycle involving: a, b, c",
+		},
+		{
+			name:     "cycle against the enabled order",
+			declared: map[string][]string{"a": {"b"}},
+			enabled:  []string{"a", "b"},
+			wantErr:  "plugin dependency cycle involving: a, b",
+		},
+		{
+			name:    "enabled twice",
+			enabled: []string{"a", "b", "a"},
+			wantErr: "plugin a is enabled more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			for _, name := range []string{"a", "b", "c", "d"} {
+				r.Register(&testPlugin{BasePlugin{name: name, after: tt.declared[name]}})
+			}
+			for _, name := range tt.enabled {
+				if err := r.Enable(name); err != nil {
+					t.Fatalf("Enable(%s) error: %v", name, err)
+				}
+			}
+			for name, after := range tt.after {
+				r.SetAfter(name, after)
+			}
+
+			g, err := r.buildGraph()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildGraph error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildGraph error: %v", err)
+			}
+			if !reflect.DeepEqual(g.order, tt.want) {
+				t.Errorf("order = %v, want %v", g.order, tt.want)
+			}
+			if !reflect.DeepEqual(g.deps, tt.wantDeps) {
+				t.Errorf("deps = %v, want %v", g.deps, tt.wantDeps)
+			}
+		})
+	}
+}
+
+func TestDependenciesOf(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&testPlugin{BasePlugin{name: "a"}})
+	r.Register(&testPlugin{BasePlugin{name: "b"}})
+	r.Register(&testPlugin{BasePlugin{name: "c", after: []string{"a"}}})
+	for _, name := range []string{"a", "b", "c"} {
+		if err := r.Enable(name); err != nil {
+			t.Fatalf("Enable(%s) error: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		index int
+		after []string // SetAfter for the plugin, nil leaves it unset
+		want  []string
+	}{
+		{name: "first plugin", index: 0, want: nil},
+		{name: "previous plugin", index: 1, want: []string{"a"}},
+		{name: "declared", index: 2, want: []string{"a"}},
+		{name: "set", index: 2, after: []string{"b"}, want: []string{"b"}},
+		{name: "set to none", index: 1, after: []string{NoDependencies}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := r.pluginOrder[tt.index]
+			if tt.after != nil {
+				r.SetAfter(name, tt.after)
+				defer delete(r.after, name)
+			}
+			if got := r.dependenciesOf(tt.index); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dependenciesOf(%s) = %#v, want %#v", name, got, tt.want)
+			}
+		})
+	}
+}
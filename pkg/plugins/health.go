@@ -0,0 +1,25 @@
+package plugins
+
+import "context"
+
+// HealthChecker is implemented by plugins that depend on an external system
+// and can tell whether it is reachable. It backs the /readyz endpoint.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// CheckHealth runs HealthCheck on every enabled plugin that implements it and
+// returns the result per plugin name
+func (r *Registry) CheckHealth(ctx context.Context) map[string]error {
+	results := make(map[string]error)
+	for _, name := range r.pluginOrder {
+		plugin, exists := r.plugins[name]
+		if !exists {
+			continue
+		}
+		if checker, ok := plugin.(HealthChecker); ok {
+			results[name] = checker.HealthCheck(ctx)
+		}
+	}
+	return results
+}
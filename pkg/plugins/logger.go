@@ -1,8 +1,16 @@
 package plugins
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/894/node-cleanup-webhook/pkg/constants"
@@ -11,18 +19,49 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// LoggerPlugin logs node deletion information
+// LoggerPlugin writes a snapshot of each deleted node to its writer, as a
+// human-readable report (pretty), one JSON object (json) or one logfmt line
+// (logfmt) per node
 type LoggerPlugin struct {
 	BasePlugin
+	format    string
+	verbosity string
+	// Wait before finishing, to demonstrate the finalizer holding deletion
+	delay time.Duration
+
+	// Serializes whole snapshots from concurrent cleanups
+	mu  sync.Mutex
+	out io.Writer
 }
 
-// NewLoggerPlugin creates a new logger plugin
-func NewLoggerPlugin(client kubernetes.Interface) *LoggerPlugin {
+// NewLoggerPlugin creates a new logger plugin writing to out (stdout if nil).
+// An unknown format or verbosity falls back to the default with a warning.
+func NewLoggerPlugin(client kubernetes.Interface, format, verbosity string, delay time.Duration, out io.Writer) *LoggerPlugin {
+	switch format {
+	case constants.LoggerFormatPretty, constants.LoggerFormatJSON, constants.LoggerFormatLogfmt:
+	default:
+		klog.Warningf("Invalid logger format %q, using %s", format, constants.LoggerFormatPretty)
+		format = constants.LoggerFormatPretty
+	}
+	switch verbosity {
+	case constants.LoggerVerbosityMinimal, constants.LoggerVerbosityInfo, constants.LoggerVerbosityDebug:
+	default:
+		klog.Warningf("Invalid logger verbosity %q, using %s", verbosity, constants.LoggerVerbosityInfo)
+		verbosity = constants.LoggerVerbosityInfo
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+
 	return &LoggerPlugin{
 		BasePlugin: BasePlugin{
 			name:   constants.LoggerPluginName,
 			client: client,
 		},
+		format:    format,
+		verbosity: verbosity,
+		delay:     delay,
+		out:       out,
 	}
 }
 
@@ -31,75 +70,288 @@ func (p *LoggerPlugin) ShouldRun(node *corev1.Node) bool {
 	return true
 }
 
-// Cleanup logs node information using structured logging
+// AlwaysRun opts out of checkpointing so every cleanup attempt is logged
+func (p *LoggerPlugin) AlwaysRun() bool {
+	return true
+}
+
+// Cleanup writes the node snapshot, then waits for the configured delay
 func (p *LoggerPlugin) Cleanup(ctx context.Context, node *corev1.Node) error {
-	// Print banner showing cleanup started
-	fmt.Printf("\n╔═══════════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║  🔄 CLEANUP STARTED FOR NODE: %-30s ║\n", node.Name)
-	fmt.Printf("╚═══════════════════════════════════════════════════════════════╝\n")
-
-	// Structured log with all node metadata
-	klog.InfoS("Node deletion initiated - starting cleanup delay",
-		"node", node.Name,
-		"createdAt", node.CreationTimestamp.Time,
-		"deletionTimestamp", node.DeletionTimestamp.Time,
-		"uid", node.UID,
-		"labelCount", len(node.Labels),
-		"conditionCount", len(node.Status.Conditions),
-		"cleanupDelay", constants.POCCleanupDelay.String(),
-	)
-
-	// POC: Sleep for 15 seconds to demonstrate that deletion waits for cleanup
-	fmt.Printf("⏳ Waiting 15 seconds to demonstrate finalizer blocking deletion...\n")
-	klog.InfoS("Simulating cleanup work - sleeping to demonstrate finalizer", "node", node.Name, "duration", "15s")
-
-	// Use select with context to respect cancellation
+	snapshot := p.snapshot(node)
+
+	var buf bytes.Buffer
+	switch p.format {
+	case constants.LoggerFormatJSON:
+		if err := json.NewEncoder(&buf).Encode(snapshot); err != nil {
+			return fmt.Errorf("failed to encode node snapshot: %w", err)
+		}
+	case constants.LoggerFormatLogfmt:
+		writeLogfmt(&buf, snapshot)
+	default:
+		writePretty(&buf, snapshot)
+	}
+
+	p.mu.Lock()
+	_, err := p.out.Write(buf.Bytes())
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to write node snapshot: %w", err)
+	}
+	klog.V(2).InfoS("Node deletion logged", "node", node.Name, "format", p.format, "verbosity", p.verbosity)
+
+	if p.delay <= 0 {
+		return nil
+	}
+
+	// Demonstrates that deletion waits for cleanup
+	klog.InfoS("Delaying cleanup to demonstrate the finalizer", "node", node.Name, "delay", p.delay)
 	select {
-	case <-time.After(constants.POCCleanupDelay):
-		// Continue with cleanup
+	case <-time.After(p.delay):
 		klog.InfoS("Cleanup delay completed", "node", node.Name)
-		fmt.Printf("✅ 15 second delay completed!\n\n")
+		return nil
 	case <-ctx.Done():
-		// Context cancelled during sleep
 		klog.InfoS("Cleanup cancelled during delay", "node", node.Name)
 		return ctx.Err()
 	}
+}
+
+// nodeSnapshot is what the logger records about a node. Verbosity minimal
+// keeps only the identity, debug adds allocatable resources, system info and
+// condition messages.
+type nodeSnapshot struct {
+	Time              time.Time           `json:"time"`
+	Node              string              `json:"node"`
+	UID               string              `json:"uid"`
+	CreatedAt         time.Time           `json:"createdAt"`
+	DeletionTimestamp *time.Time          `json:"deletionTimestamp,omitempty"`
+	Labels            map[string]string   `json:"labels,omitempty"`
+	Annotations       map[string]string   `json:"annotations,omitempty"`
+	Taints            []taintSnapshot     `json:"taints,omitempty"`
+	Conditions        []conditionSnapshot `json:"conditions,omitempty"`
+	Capacity          map[string]string   `json:"capacity,omitempty"`
+	Allocatable       map[string]string   `json:"allocatable,omitempty"`
+	Addresses         []addressSnapshot   `json:"addresses,omitempty"`
+	NodeInfo          *nodeInfoSnapshot   `json:"nodeInfo,omitempty"`
+}
 
-	// Print summary after delay
-	fmt.Printf("╔═══════════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║  🗑️  FINALIZING DELETION FOR NODE: %-26s ║\n", node.Name)
-	fmt.Printf("╠═══════════════════════════════════════════════════════════════╣\n")
-	fmt.Printf("║  Created:     %-47s ║\n", node.CreationTimestamp.Time.Format(time.RFC3339))
-	fmt.Printf("║  Deleting At: %-47s ║\n", node.DeletionTimestamp.Time.Format(time.RFC3339))
-	fmt.Printf("║  UID:         %-47s ║\n", node.UID)
-	fmt.Printf("╚═══════════════════════════════════════════════════════════════╝\n")
-
-	// Log labels as structured data
-	if len(node.Labels) > 0 {
-		klog.V(1).InfoS("Node labels", "node", node.Name, "labels", node.Labels)
-		fmt.Printf("\n📋 Labels (%d):\n", len(node.Labels))
-		for k, v := range node.Labels {
-			fmt.Printf("   • %s: %s\n", k, v)
+type taintSnapshot struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+type conditionSnapshot struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type addressSnapshot struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+type nodeInfoSnapshot struct {
+	KubeletVersion          string `json:"kubeletVersion"`
+	OSImage                 string `json:"osImage"`
+	KernelVersion           string `json:"kernelVersion"`
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion"`
+	Architecture            string `json:"architecture"`
+}
+
+func (p *LoggerPlugin) snapshot(node *corev1.Node) nodeSnapshot {
+	s := nodeSnapshot{
+		Time:      time.Now().UTC(),
+		Node:      node.Name,
+		UID:       string(node.UID),
+		CreatedAt: node.CreationTimestamp.UTC(),
+	}
+	if node.DeletionTimestamp != nil {
+		deletedAt := node.DeletionTimestamp.UTC()
+		s.DeletionTimestamp = &deletedAt
+	}
+	if p.verbosity == constants.LoggerVerbosityMinimal {
+		return s
+	}
+
+	s.Labels = node.Labels
+	s.Annotations = node.Annotations
+	for _, taint := range node.Spec.Taints {
+		s.Taints = append(s.Taints, taintSnapshot{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)})
+	}
+	for _, cond := range node.Status.Conditions {
+		condition := conditionSnapshot{Type: string(cond.Type), Status: string(cond.Status), Reason: cond.Reason}
+		if p.verbosity == constants.LoggerVerbosityDebug {
+			condition.Message = cond.Message
 		}
+		s.Conditions = append(s.Conditions, condition)
+	}
+	s.Capacity = resourceStrings(node.Status.Capacity)
+	for _, addr := range node.Status.Addresses {
+		s.Addresses = append(s.Addresses, addressSnapshot{Type: string(addr.Type), Address: addr.Address})
 	}
 
-	// Log conditions as structured data
-	if len(node.Status.Conditions) > 0 {
-		conditions := make(map[string]string)
-		for _, cond := range node.Status.Conditions {
-			conditions[string(cond.Type)] = string(cond.Status)
+	if p.verbosity == constants.LoggerVerbosityDebug {
+		s.Allocatable = resourceStrings(node.Status.Allocatable)
+		info := node.Status.NodeInfo
+		s.NodeInfo = &nodeInfoSnapshot{
+			KubeletVersion:          info.KubeletVersion,
+			OSImage:                 info.OSImage,
+			KernelVersion:           info.KernelVersion,
+			ContainerRuntimeVersion: info.ContainerRuntimeVersion,
+			Architecture:            info.Architecture,
 		}
-		klog.V(1).InfoS("Node conditions", "node", node.Name, "conditions", conditions)
+	}
+	return s
+}
 
-		fmt.Printf("\n🏥 Conditions (%d):\n", len(node.Status.Conditions))
-		for _, cond := range node.Status.Conditions {
-			fmt.Printf("   • %s: %s (reason: %s)\n", cond.Type, cond.Status, cond.Reason)
+func resourceStrings(resources corev1.ResourceList) map[string]string {
+	if len(resources) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(resources))
+	for name, quantity := range resources {
+		values[string(name)] = quantity.String()
+	}
+	return values
+}
+
+// writePretty writes the snapshot as a human-readable report
+func writePretty(w *bytes.Buffer, s nodeSnapshot) {
+	fmt.Fprintf(w, "\n╔═══════════════════════════════════════════════════════════════╗\n")
+	fmt.Fprintf(w, "║  🗑️  NODE DELETION: %-42s ║\n", s.Node)
+	fmt.Fprintf(w, "╠═══════════════════════════════════════════════════════════════╣\n")
+	fmt.Fprintf(w, "║  Created:     %-47s ║\n", s.CreatedAt.Format(time.RFC3339))
+	if s.DeletionTimestamp != nil {
+		fmt.Fprintf(w, "║  Deleting At: %-47s ║\n", s.DeletionTimestamp.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "║  UID:         %-47s ║\n", s.UID)
+	fmt.Fprintf(w, "╚═══════════════════════════════════════════════════════════════╝\n")
+
+	writePrettyMap(w, "📋 Labels", s.Labels)
+	writePrettyMap(w, "📝 Annotations", s.Annotations)
+	if len(s.Taints) > 0 {
+		fmt.Fprintf(w, "\n🚫 Taints (%d):\n", len(s.Taints))
+		for _, taint := range s.Taints {
+			fmt.Fprintf(w, "   • %s\n", formatTaint(taint))
+		}
+	}
+	if len(s.Conditions) > 0 {
+		fmt.Fprintf(w, "\n🏥 Conditions (%d):\n", len(s.Conditions))
+		for _, cond := range s.Conditions {
+			fmt.Fprintf(w, "   • %s: %s (reason: %s)\n", cond.Type, cond.Status, cond.Reason)
+			if cond.Message != "" {
+				fmt.Fprintf(w, "     %s\n", cond.Message)
+			}
+		}
+	}
+	writePrettyMap(w, "📦 Capacity", s.Capacity)
+	writePrettyMap(w, "📦 Allocatable", s.Allocatable)
+	if len(s.Addresses) > 0 {
+		fmt.Fprintf(w, "\n🌐 Addresses (%d):\n", len(s.Addresses))
+		for _, addr := range s.Addresses {
+			fmt.Fprintf(w, "   • %s: %s\n", addr.Type, addr.Address)
+		}
+	}
+	if info := s.NodeInfo; info != nil {
+		fmt.Fprintf(w, "\n🖥️  System:\n")
+		fmt.Fprintf(w, "   • kubelet %s, %s, kernel %s, %s, %s\n", info.KubeletVersion, info.OSImage,
+			info.KernelVersion, info.ContainerRuntimeVersion, info.Architecture)
+	}
+	fmt.Fprintln(w)
+}
+
+func writePrettyMap(w *bytes.Buffer, title string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", title, len(values))
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "   • %s: %s\n", key, values[key])
+	}
+}
+
+// writeLogfmt writes the snapshot as one logfmt line. Maps and lists are
+// flattened into dotted keys, e.g. label.<key>=<value> and condition.Ready=True.
+func writeLogfmt(w *bytes.Buffer, s nodeSnapshot) {
+	pair := func(key, value string) {
+		if w.Len() > 0 {
+			w.WriteByte(' ')
+		}
+		w.WriteString(key)
+		w.WriteByte('=')
+		w.WriteString(logfmtValue(value))
+	}
+	pair("time", s.Time.Format(time.RFC3339))
+	pair("node", s.Node)
+	pair("uid", s.UID)
+	pair("createdAt", s.CreatedAt.Format(time.RFC3339))
+	if s.DeletionTimestamp != nil {
+		pair("deletionTimestamp", s.DeletionTimestamp.Format(time.RFC3339))
+	}
+	for _, key := range sortedKeys(s.Labels) {
+		pair("label."+key, s.Labels[key])
+	}
+	for _, key := range sortedKeys(s.Annotations) {
+		pair("annotation."+key, s.Annotations[key])
+	}
+	for _, taint := range s.Taints {
+		value := taint.Effect
+		if taint.Value != "" {
+			value = taint.Value + ":" + taint.Effect
 		}
+		pair("taint."+taint.Key, value)
+	}
+	for _, cond := range s.Conditions {
+		pair("condition."+cond.Type, cond.Status)
+		if cond.Reason != "" {
+			pair("condition."+cond.Type+".reason", cond.Reason)
+		}
+		if cond.Message != "" {
+			pair("condition."+cond.Type+".message", cond.Message)
+		}
+	}
+	for _, key := range sortedKeys(s.Capacity) {
+		pair("capacity."+key, s.Capacity[key])
+	}
+	for _, key := range sortedKeys(s.Allocatable) {
+		pair("allocatable."+key, s.Allocatable[key])
 	}
+	for _, addr := range s.Addresses {
+		pair("address."+addr.Type, addr.Address)
+	}
+	if info := s.NodeInfo; info != nil {
+		pair("nodeInfo.kubeletVersion", info.KubeletVersion)
+		pair("nodeInfo.osImage", info.OSImage)
+		pair("nodeInfo.kernelVersion", info.KernelVersion)
+		pair("nodeInfo.containerRuntimeVersion", info.ContainerRuntimeVersion)
+		pair("nodeInfo.architecture", info.Architecture)
+	}
+	w.WriteByte('\n')
+}
 
-	fmt.Printf("\n╔═══════════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║  ✅ CLEANUP COMPLETED - Node can now be deleted              ║\n")
-	fmt.Printf("╚═══════════════════════════════════════════════════════════════╝\n\n")
+// logfmtValue quotes values that are empty or contain spaces, quotes or '='
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"=\\") || !strconv.CanBackquote(value) {
+		return strconv.Quote(value)
+	}
+	return value
+}
 
-	return nil
+// formatTaint renders a taint like kubectl: key=value:Effect
+func formatTaint(taint taintSnapshot) string {
+	if taint.Value == "" {
+		return taint.Key + ":" + taint.Effect
+	}
+	return taint.Key + "=" + taint.Value + ":" + taint.Effect
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
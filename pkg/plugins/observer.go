@@ -0,0 +1,84 @@
+package plugins
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Observer is notified as RunAll moves through the plugin pipeline, so callers
+// can record per-plugin results without the plugins knowing about it
+type Observer interface {
+	// PluginStarted is called before the plugin's Cleanup runs
+	PluginStarted(ctx context.Context, node *corev1.Node, name string)
+
+	// PluginSucceeded is called after the plugin's Cleanup returned nil
+	PluginSucceeded(ctx context.Context, node *corev1.Node, name string)
+
+	// PluginFailed is called after the plugin's Cleanup returned an error
+	PluginFailed(ctx context.Context, node *corev1.Node, name string, err error)
+
+	// PluginSkipped is called for plugins that did not run, with the reason
+	PluginSkipped(ctx context.Context, node *corev1.Node, name string, reason string)
+
+	// PluginRolledBack is called after a Compensator's Rollback returned nil
+	PluginRolledBack(ctx context.Context, node *corev1.Node, name string)
+
+	// PluginRollbackFailed is called after a Compensator's Rollback returned an error
+	PluginRollbackFailed(ctx context.Context, node *corev1.Node, name string, err error)
+}
+
+// nopObserver is used when RunAll is given no observer
+type nopObserver struct{}
+
+func (nopObserver) PluginStarted(context.Context, *corev1.Node, string)               {}
+func (nopObserver) PluginSucceeded(context.Context, *corev1.Node, string)             {}
+func (nopObserver) PluginFailed(context.Context, *corev1.Node, string, error)         {}
+func (nopObserver) PluginSkipped(context.Context, *corev1.Node, string, string)       {}
+func (nopObserver) PluginRolledBack(context.Context, *corev1.Node, string)            {}
+func (nopObserver) PluginRollbackFailed(context.Context, *corev1.Node, string, error) {}
+
+// Observers fans each notification out to every observer in order
+type Observers []Observer
+
+// PluginStarted implements Observer
+func (o Observers) PluginStarted(ctx context.Context, node *corev1.Node, name string) {
+	for _, observer := range o {
+		observer.PluginStarted(ctx, node, name)
+	}
+}
+
+// PluginSucceeded implements Observer
+func (o Observers) PluginSucceeded(ctx context.Context, node *corev1.Node, name string) {
+	for _, observer := range o {
+		observer.PluginSucceeded(ctx, node, name)
+	}
+}
+
+// PluginFailed implements Observer
+func (o Observers) PluginFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	for _, observer := range o {
+		observer.PluginFailed(ctx, node, name, err)
+	}
+}
+
+// PluginSkipped implements Observer
+func (o Observers) PluginSkipped(ctx context.Context, node *corev1.Node, name string, reason string) {
+	for _, observer := range o {
+		observer.PluginSkipped(ctx, node, name, reason)
+	}
+}
+
+// PluginRolledBack implements Observer
+func (o Observers) PluginRolledBack(ctx context.Context, node *corev1.Node, name string) {
+	for _, observer := range o {
+		observer.PluginRolledBack(ctx, node, name)
+	}
+}
+
+// PluginRollbackFailed implements Observer
+func (o Observers) PluginRollbackFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	for _, observer := range o {
+		observer.PluginRollbackFailed(ctx, node, name, err)
+	}
+}
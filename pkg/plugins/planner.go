@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// Planner is implemented by plugins that can describe what their Cleanup
+// would do for a node without doing it. It is called in dry-run mode.
+type Planner interface {
+	// Plan returns the actions Cleanup would take, in order
+	Plan(ctx context.Context, node *corev1.Node) ([]string, error)
+}
+
+// PlannedPlugin is one plugin's entry in a dry-run plan
+type PlannedPlugin struct {
+	Name string
+	// WouldRun is false when the checkpoint or ShouldRun would skip the plugin
+	WouldRun bool
+	// SkipReason explains why the plugin would not run
+	SkipReason string
+	// After lists the enabled plugins it would wait for
+	After []string
+	// Actions is the plugin's Plan; empty when it does not implement Planner
+	Actions []string
+	// PlanErr is the error returned by Plan
+	PlanErr error
+}
+
+// PlanAll computes what RunAll would do for the node without running any
+// Cleanup. Plans are listed in the order RunAll would start the plugins.
+func (r *Registry) PlanAll(ctx context.Context, node *corev1.Node, checkpoint Checkpoint) ([]PlannedPlugin, error) {
+	g := r.graph
+	if g == nil {
+		var err error
+		if g, err = r.buildGraph(); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := make([]PlannedPlugin, 0, len(g.order))
+	for _, name := range g.order {
+		plugin := r.plugins[name]
+		entry := PlannedPlugin{Name: name, After: g.deps[name]}
+
+		switch {
+		case checkpoint != nil && checkpoint.Completed(name) && !alwaysRuns(plugin):
+			entry.SkipReason = "completed by a previous attempt"
+		case !plugin.ShouldRun(node):
+			entry.SkipReason = "conditions not met"
+		default:
+			entry.WouldRun = true
+		}
+
+		if planner, ok := plugin.(Planner); ok && entry.WouldRun {
+			// Handed over by channel: an abandoned Plan must not write entry
+			actions := make(chan []string, 1)
+			entry.PlanErr = r.runWithTimeout(ctx, plugin, node, func(ctx context.Context) error {
+				planned, err := planner.Plan(ctx, node)
+				actions <- planned
+				return err
+			})
+			if entry.PlanErr == nil {
+				entry.Actions = <-actions
+			} else {
+				klog.ErrorS(entry.PlanErr, "Plugin plan failed", "plugin", name, "node", node.Name)
+			}
+		}
+
+		plan = append(plan, entry)
+	}
+	return plan, nil
+}
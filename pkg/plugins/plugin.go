@@ -2,10 +2,15 @@ package plugins
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/894/node-cleanup-webhook/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 )
 
@@ -21,19 +26,41 @@ type Plugin interface {
 	Cleanup(ctx context.Context, node *corev1.Node) error
 }
 
+// EventRecorderSetter is implemented by plugins that record their own events
+// against the Node. BasePlugin implements it.
+type EventRecorderSetter interface {
+	SetEventRecorder(recorder record.EventRecorder)
+}
+
 // Registry manages all available cleanup plugins
 type Registry struct {
-	plugins      map[string]Plugin
-	enabled      map[string]bool
-	pluginOrder  []string // Execution order from ENABLED_PLUGINS env var
+	plugins     map[string]Plugin
+	enabled     map[string]bool
+	pluginOrder []string // Execution order from ENABLED_PLUGINS env var
+	recorder    record.EventRecorder
+	// Per-plugin Cleanup timeouts; plugins without one use defaultTimeout
+	timeouts       map[string]time.Duration
+	defaultTimeout time.Duration
+	// Dependency graph of the enabled plugins, see BuildGraph
+	graph *graph
+	// Plugins configured to require manual approval, see ApprovalRequiredBy
+	requireApproval map[string]bool
+	// Dependencies configured with SetAfter, replacing declared ones
+	after map[string][]string
+	// Plugin runs abandoned after their timeout that have not returned yet,
+	// keyed by abandonedKey
+	abandoned sync.Map
 }
 
 // NewRegistry creates a new plugin registry
 func NewRegistry() *Registry {
 	return &Registry{
-		plugins:     make(map[string]Plugin),
-		enabled:     make(map[string]bool),
-		pluginOrder: []string{},
+		plugins:         make(map[string]Plugin),
+		enabled:         make(map[string]bool),
+		pluginOrder:     []string{},
+		timeouts:        make(map[string]time.Duration),
+		requireApproval: make(map[string]bool),
+		after:           make(map[string][]string),
 	}
 }
 
@@ -41,9 +68,27 @@ func NewRegistry() *Registry {
 func (r *Registry) Register(plugin Plugin) {
 	name := plugin.Name()
 	r.plugins[name] = plugin
+	if r.recorder != nil {
+		setEventRecorder(plugin, r.recorder)
+	}
 	klog.V(2).Infof("Registered plugin: %s", name)
 }
 
+// SetEventRecorder makes the recorder available to every registered plugin,
+// including plugins registered later
+func (r *Registry) SetEventRecorder(recorder record.EventRecorder) {
+	r.recorder = recorder
+	for _, plugin := range r.plugins {
+		setEventRecorder(plugin, recorder)
+	}
+}
+
+func setEventRecorder(plugin Plugin, recorder record.EventRecorder) {
+	if setter, ok := plugin.(EventRecorderSetter); ok {
+		setter.SetEventRecorder(recorder)
+	}
+}
+
 // Enable enables a plugin by name and records the execution order
 func (r *Registry) Enable(name string) error {
 	if _, exists := r.plugins[name]; !exists {
@@ -51,6 +96,7 @@ func (r *Registry) Enable(name string) error {
 	}
 	r.enabled[name] = true
 	r.pluginOrder = append(r.pluginOrder, name)
+	r.graph = nil
 	klog.Infof("✅ Enabled cleanup plugin: %s (position %d)", name, len(r.pluginOrder))
 	return nil
 }
@@ -61,41 +107,151 @@ func (r *Registry) Disable(name string) {
 	klog.Infof("Disabled cleanup plugin: %s", name)
 }
 
-// RunAll runs all enabled plugins in the order they were enabled (from ENABLED_PLUGINS env var)
-func (r *Registry) RunAll(ctx context.Context, node *corev1.Node) error {
-	klog.InfoS("Starting cleanup plugins", "node", node.Name, "pluginOrder", r.pluginOrder)
-
-	ranCount := 0
+// RunAll runs all enabled plugins for the node, each after its dependencies
+// (by default the plugin enabled before it, see dependenciesOf). Plugins
+// without dependencies between them run concurrently; a plugin whose
+// dependency failed is skipped, while independent plugins still run. Errors
+// of all failed plugins are joined.
+// Plugins recorded as completed in the checkpoint are skipped unless they opt
+// out via AlwaysRunner. A nil checkpoint runs every plugin. The observer, if
+// not nil, is notified of each plugin's outcome. Each plugin runs under its
+// own timeout; exceeding it returns a *TimeoutError.
+func (r *Registry) RunAll(ctx context.Context, node *corev1.Node, checkpoint Checkpoint, observer Observer) error {
+	if observer == nil {
+		observer = nopObserver{}
+	}
 
-	// Execute plugins in the order they were enabled
-	for i, name := range r.pluginOrder {
-		plugin, exists := r.plugins[name]
-		if !exists {
-			klog.ErrorS(nil, "Plugin not found in registry", "plugin", name)
-			continue
+	g := r.graph
+	if g == nil {
+		var err error
+		if g, err = r.buildGraph(); err != nil {
+			return err
 		}
+	}
 
-		// Skip if plugin should not run for this node
-		if !plugin.ShouldRun(node) {
-			klog.V(2).InfoS("Plugin skipped - conditions not met", "plugin", name, "node", node.Name)
-			continue
-		}
+	klog.InfoS("Starting cleanup plugins", "node", node.Name, "pluginOrder", g.order)
+
+	// Each plugin waits for the runs of its dependencies to finish
+	runs := make(map[string]*pluginRun, len(g.order))
+	for _, name := range g.order {
+		runs[name] = &pluginRun{done: make(chan struct{})}
+	}
 
-		klog.InfoS("Running plugin", "plugin", name, "position", i+1, "total", len(r.pluginOrder), "node", node.Name)
+	var wg sync.WaitGroup
+	for i, name := range g.order {
+		wg.Add(1)
+		go func(position int, name string) {
+			defer wg.Done()
+			run := runs[name]
+			defer close(run.done)
 
-		if err := plugin.Cleanup(ctx, node); err != nil {
-			klog.ErrorS(err, "Plugin execution failed", "plugin", name, "node", node.Name)
-			return fmt.Errorf("plugin %s failed: %w", name, err)
-		}
+			for _, dep := range g.deps[name] {
+				<-runs[dep].done
+				if runs[dep].failed {
+					klog.InfoS("Plugin skipped - dependency failed", "plugin", name, "dependency", dep, "node", node.Name)
+					observer.PluginSkipped(ctx, node, name, fmt.Sprintf("dependency %s failed", dep))
+					metrics.PluginRunsTotal.WithLabelValues(name, metrics.ResultSkipped).Inc()
+					run.failed = true
+					return
+				}
+			}
+
+			run.outcome, run.err = r.runPlugin(ctx, node, name, position, len(g.order), checkpoint, observer)
+			run.failed = run.err != nil
+		}(i, name)
+	}
+	wg.Wait()
 
-		klog.InfoS("Plugin completed successfully", "plugin", name, "node", node.Name)
-		ranCount++
+	ranCount := 0
+	resumedCount := 0
+	var errs []error
+	for _, name := range g.order {
+		run := runs[name]
+		switch {
+		case run.err != nil:
+			errs = append(errs, run.err)
+		case run.outcome == outcomeRan:
+			ranCount++
+		case run.outcome == outcomeResumed:
+			resumedCount++
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
-	klog.InfoS("Cleanup completed", "node", node.Name, "executedPlugins", ranCount, "totalPlugins", len(r.pluginOrder))
+	klog.InfoS("Cleanup completed", "node", node.Name, "executedPlugins", ranCount, "resumedPlugins", resumedCount, "totalPlugins", len(g.order))
 	return nil
 }
 
+// pluginOutcome is how a plugin was handled within one RunAll
+type pluginOutcome int
+
+const (
+	outcomeSkipped pluginOutcome = iota
+	outcomeResumed
+	outcomeRan
+)
+
+// pluginRun tracks one plugin within RunAll; fields are written before done
+// is closed
+type pluginRun struct {
+	done    chan struct{}
+	outcome pluginOutcome
+	failed  bool
+	err     error
+}
+
+// runPlugin runs a single plugin unless the checkpoint or ShouldRun skips it
+func (r *Registry) runPlugin(ctx context.Context, node *corev1.Node, name string, position, total int, checkpoint Checkpoint, observer Observer) (pluginOutcome, error) {
+	plugin := r.plugins[name]
+
+	// Skip if a previous attempt already completed this plugin
+	if checkpoint != nil && checkpoint.Completed(name) && !alwaysRuns(plugin) {
+		klog.InfoS("Plugin skipped - completed by a previous attempt", "plugin", name, "node", node.Name)
+		observer.PluginSkipped(ctx, node, name, "completed by a previous attempt")
+		metrics.PluginRunsTotal.WithLabelValues(name, metrics.ResultSkipped).Inc()
+		return outcomeResumed, nil
+	}
+
+	// Skip if plugin should not run for this node
+	if !plugin.ShouldRun(node) {
+		klog.V(2).InfoS("Plugin skipped - conditions not met", "plugin", name, "node", node.Name)
+		observer.PluginSkipped(ctx, node, name, "conditions not met")
+		metrics.PluginRunsTotal.WithLabelValues(name, metrics.ResultSkipped).Inc()
+		return outcomeSkipped, nil
+	}
+
+	klog.InfoS("Running plugin", "plugin", name, "position", position+1, "total", total, "node", node.Name)
+
+	observer.PluginStarted(ctx, node, name)
+	start := time.Now()
+	err := r.runWithTimeout(ctx, plugin, node, func(ctx context.Context) error {
+		return plugin.Cleanup(ctx, node)
+	})
+	metrics.ObservePlugin(name, err, start)
+	if IsTimeout(err) {
+		klog.ErrorS(err, "Plugin timed out", "plugin", name, "node", node.Name, "timeout", r.timeoutFor(name))
+		observer.PluginFailed(ctx, node, name, err)
+		return outcomeRan, err
+	}
+	if err != nil {
+		klog.ErrorS(err, "Plugin execution failed", "plugin", name, "node", node.Name)
+		observer.PluginFailed(ctx, node, name, err)
+		return outcomeRan, fmt.Errorf("plugin %s failed: %w", name, err)
+	}
+
+	klog.InfoS("Plugin completed successfully", "plugin", name, "node", node.Name)
+	observer.PluginSucceeded(ctx, node, name)
+
+	if checkpoint != nil && !alwaysRuns(plugin) {
+		if err := checkpoint.MarkCompleted(ctx, name); err != nil {
+			return outcomeRan, fmt.Errorf("plugin %s completed but checkpoint failed: %w", name, err)
+		}
+	}
+	return outcomeRan, nil
+}
+
 // GetEnabledPlugins returns a list of enabled plugin names
 func (r *Registry) GetEnabledPlugins() []string {
 	var enabled []string
@@ -109,11 +265,32 @@ func (r *Registry) GetEnabledPlugins() []string {
 
 // BasePlugin provides common functionality for plugins
 type BasePlugin struct {
-	name   string
-	client kubernetes.Interface
+	name     string
+	client   kubernetes.Interface
+	recorder record.EventRecorder
+	// Plugins that must complete before this one
+	after []string
 }
 
 // Name returns the plugin name
 func (b *BasePlugin) Name() string {
 	return b.name
 }
+
+// After implements Dependent
+func (b *BasePlugin) After() []string {
+	return b.after
+}
+
+// SetEventRecorder implements EventRecorderSetter
+func (b *BasePlugin) SetEventRecorder(recorder record.EventRecorder) {
+	b.recorder = recorder
+}
+
+// Recorder returns the event recorder, discarding events if none was set
+func (b *BasePlugin) Recorder() record.EventRecorder {
+	if b.recorder == nil {
+		return &record.FakeRecorder{}
+	}
+	return b.recorder
+}
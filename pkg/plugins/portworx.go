@@ -7,7 +7,9 @@ import (
 
 	"github.com/894/node-cleanup-webhook/pkg/constants"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 )
 
@@ -15,13 +17,26 @@ import (
 type PortworxPlugin struct {
 	BasePlugin
 	labelSelector string
+	selector      labels.Selector
+	// Cached nodes, for the quorum check on every deletion
+	nodeLister corelisters.NodeLister
+	// Portworx nodes that must remain after a deletion
+	minNodes int
 }
 
-// NewPortworxPlugin creates a new Portworx cleanup plugin
-func NewPortworxPlugin(client kubernetes.Interface, labelSelector string) *PortworxPlugin {
+// NewPortworxPlugin creates a new Portworx cleanup plugin. nodeLister must be
+// backed by a synced informer before deletions are validated.
+func NewPortworxPlugin(client kubernetes.Interface, nodeLister corelisters.NodeLister, labelSelector string, minNodes int) *PortworxPlugin {
 	if labelSelector == "" {
 		labelSelector = constants.DefaultPortworxLabelSelector
 	}
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		klog.ErrorS(err, "Invalid Portworx label selector, using the default", "labelSelector", labelSelector,
+			"default", constants.DefaultPortworxLabelSelector)
+		labelSelector = constants.DefaultPortworxLabelSelector
+		selector, _ = labels.Parse(labelSelector)
+	}
 
 	return &PortworxPlugin{
 		BasePlugin: BasePlugin{
@@ -29,20 +44,28 @@ func NewPortworxPlugin(client kubernetes.Interface, labelSelector string) *Portw
 			client: client,
 		},
 		labelSelector: labelSelector,
+		selector:      selector,
+		nodeLister:    nodeLister,
+		minNodes:      minNodes,
 	}
 }
 
-// ShouldRun checks if this node has Portworx enabled
-func (p *PortworxPlugin) ShouldRun(node *corev1.Node) bool {
-	// Check if node has the Portworx label
-	if val, ok := node.Labels[constants.PortworxEnabledLabel]; ok && val == constants.PortworxEnabledValue {
-		klog.V(2).InfoS("Portworx node detected", "node", node.Name, "label", constants.DefaultPortworxLabelSelector)
+// isPortworxNode reports whether the node runs Portworx: it matches the label
+// selector or carries the px/status label. ShouldRun and the quorum count in
+// Validate both use it, so they agree on who is a Portworx node.
+func (p *PortworxPlugin) isPortworxNode(node *corev1.Node) bool {
+	if p.selector.Matches(labels.Set(node.Labels)) {
 		return true
 	}
+	_, ok := node.Labels[constants.PortworxStatusLabel]
+	return ok
+}
 
-	// Alternative: check for px/status label
-	if status, ok := node.Labels[constants.PortworxStatusLabel]; ok {
-		klog.V(2).InfoS("Portworx node detected", "node", node.Name, "label", constants.PortworxStatusLabel, "status", status)
+// ShouldRun checks if this node has Portworx enabled
+func (p *PortworxPlugin) ShouldRun(node *corev1.Node) bool {
+	if p.isPortworxNode(node) {
+		klog.V(2).InfoS("Portworx node detected", "node", node.Name, "labelSelector", p.labelSelector,
+			"status", node.Labels[constants.PortworxStatusLabel])
 		return true
 	}
 
@@ -50,6 +73,39 @@ func (p *PortworxPlugin) ShouldRun(node *corev1.Node) bool {
 	return false
 }
 
+// Validate refuses the deletion when it would leave fewer than minNodes
+// Portworx nodes, which would lose the storage quorum
+func (p *PortworxPlugin) Validate(ctx context.Context, node *corev1.Node) error {
+	nodes, err := p.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("cannot verify Portworx quorum: %w", err)
+	}
+
+	remaining := 0
+	for _, other := range nodes {
+		if other.Name != node.Name && other.DeletionTimestamp == nil && p.isPortworxNode(other) {
+			remaining++
+		}
+	}
+
+	klog.V(2).InfoS("Portworx quorum check", "node", node.Name, "remainingNodes", remaining, "minNodes", p.minNodes)
+	if remaining < p.minNodes {
+		return fmt.Errorf("deleting %s would leave %d Portworx nodes, at least %d are required for quorum",
+			node.Name, remaining, p.minNodes)
+	}
+	return nil
+}
+
+// Plan describes the decommission steps Cleanup would take
+func (p *PortworxPlugin) Plan(ctx context.Context, node *corev1.Node) ([]string, error) {
+	return []string{
+		fmt.Sprintf("validate Portworx status of %s", node.Name),
+		fmt.Sprintf("initiate decommission of %s", node.Name),
+		"migrate storage replicas to the remaining Portworx nodes",
+		fmt.Sprintf("remove %s from the Portworx cluster", node.Name),
+	}, nil
+}
+
 // Cleanup performs Portworx decommissioning
 func (p *PortworxPlugin) Cleanup(ctx context.Context, node *corev1.Node) error {
 	klog.InfoS("Starting Portworx decommission", "node", node.Name, "labelSelector", p.labelSelector)
@@ -62,16 +118,24 @@ func (p *PortworxPlugin) Cleanup(ctx context.Context, node *corev1.Node) error {
 
 	// For now, simulate the decommission process with structured logging
 	klog.InfoS("Portworx decommission step", "node", node.Name, "step", "checking_status", "action", "validate_node")
-	time.Sleep(500 * time.Millisecond)
+	if err := wait(ctx, 500*time.Millisecond); err != nil {
+		return err
+	}
 
 	klog.InfoS("Portworx decommission step", "node", node.Name, "step", "starting_decommission", "action", "initiate")
-	time.Sleep(1 * time.Second)
+	if err := wait(ctx, 1*time.Second); err != nil {
+		return err
+	}
 
 	klog.InfoS("Portworx decommission step", "node", node.Name, "step", "draining_storage", "action", "migrate_data")
-	time.Sleep(500 * time.Millisecond)
+	if err := wait(ctx, 500*time.Millisecond); err != nil {
+		return err
+	}
 
 	klog.InfoS("Portworx decommission step", "node", node.Name, "step", "removing_node", "action", "cluster_removal")
-	time.Sleep(500 * time.Millisecond)
+	if err := wait(ctx, 500*time.Millisecond); err != nil {
+		return err
+	}
 
 	// Example implementation:
 	// if err := p.callPortworxAPI(ctx, node.Name); err != nil {
@@ -80,9 +144,21 @@ func (p *PortworxPlugin) Cleanup(ctx context.Context, node *corev1.Node) error {
 	// }
 
 	klog.InfoS("Portworx decommission completed", "node", node.Name, "status", "success")
+	p.Recorder().Event(node, corev1.EventTypeNormal, constants.ReasonPortworxDecommissioned, "Portworx node decommissioned")
 	return nil
 }
 
+// wait pauses for d, giving up when ctx ends so that a timed out or
+// cancelled decommission stops instead of running on in the background
+func wait(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // callPortworxAPI calls the Portworx REST API (example implementation)
 func (p *PortworxPlugin) callPortworxAPI(ctx context.Context, nodeName string) error {
 	// Example implementation:
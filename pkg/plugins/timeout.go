@@ -0,0 +1,120 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// TimeoutError is returned when a plugin did not finish within its timeout.
+// It matches context.DeadlineExceeded with errors.Is.
+type TimeoutError struct {
+	Plugin  string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("plugin %s timed out after %v", e.Plugin, e.Timeout)
+}
+
+// Is reports a TimeoutError as context.DeadlineExceeded
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// IsTimeout reports whether err was caused by a plugin exceeding its timeout
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// SetDefaultTimeout sets the timeout of plugins without their own timeout.
+// Zero means no timeout.
+func (r *Registry) SetDefaultTimeout(timeout time.Duration) {
+	r.defaultTimeout = timeout
+}
+
+// SetTimeout overrides the default timeout for one plugin. Zero means no timeout.
+func (r *Registry) SetTimeout(name string, timeout time.Duration) {
+	r.timeouts[name] = timeout
+}
+
+// timeoutFor returns the timeout that applies to a plugin
+func (r *Registry) timeoutFor(name string) time.Duration {
+	if timeout, ok := r.timeouts[name]; ok {
+		return timeout
+	}
+	return r.defaultTimeout
+}
+
+// abandonedKey identifies the runs of a plugin for a node
+func abandonedKey(plugin Plugin, node *corev1.Node) string {
+	return node.Name + "/" + plugin.Name()
+}
+
+// runWithTimeout runs fn, one of the plugin's operations, under the plugin's
+// deadline. A plugin that ignores its context is abandoned shortly after the
+// deadline passes so that it cannot block the worker; its goroutine is left to finish
+// in the background. Until it does, further operations of the plugin for the
+// node are refused, so a retry never runs alongside the abandoned run.
+func (r *Registry) runWithTimeout(ctx context.Context, plugin Plugin, node *corev1.Node, fn func(ctx context.Context) error) error {
+	key := abandonedKey(plugin, node)
+	if _, running := r.abandoned.Load(key); running {
+		return fmt.Errorf("plugin %s is still running for node %s from an attempt abandoned after its timeout", plugin.Name(), node.Name)
+	}
+
+	timeout := r.timeoutFor(plugin.Name())
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	pluginCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(pluginCtx)
+	}()
+
+	var err error
+	abandoned := false
+	select {
+	case err = <-done:
+	case <-pluginCtx.Done():
+		// A plugin that honours its context returns right away
+		grace := time.NewTimer(constants.PluginAbandonGrace)
+		defer grace.Stop()
+		select {
+		case err = <-done:
+		case <-grace.C:
+			if ctx.Err() == nil {
+				klog.ErrorS(nil, "Plugin ignored its deadline - abandoning it", "plugin", plugin.Name(),
+					"node", node.Name, "timeout", timeout)
+			}
+			abandoned = true
+			r.abandoned.Store(key, struct{}{})
+			go func() {
+				abandonedErr := <-done
+				r.abandoned.Delete(key)
+				klog.InfoS("Abandoned plugin run returned", "plugin", plugin.Name(), "node", node.Name, "error", abandonedErr)
+			}()
+			err = pluginCtx.Err()
+		}
+	}
+
+	// Only our own deadline is a timeout; cancellation of ctx is a shutdown.
+	// A plugin that failed on its own just as the deadline passed keeps its
+	// error.
+	if ctx.Err() != nil || !errors.Is(pluginCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if abandoned || errors.Is(err, context.DeadlineExceeded) {
+		return &TimeoutError{Plugin: plugin.Name(), Timeout: timeout}
+	}
+	return err
+}
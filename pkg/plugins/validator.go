@@ -0,0 +1,45 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// Validator is implemented by plugins that can refuse a node deletion before
+// it starts, e.g. when removing the node would break a storage quorum
+type Validator interface {
+	// Validate returns an error explaining why the node must not be deleted
+	Validate(ctx context.Context, node *corev1.Node) error
+}
+
+// ValidateAll asks every enabled plugin that would run for the node whether
+// the deletion is safe. All objections are collected into one error.
+func (r *Registry) ValidateAll(ctx context.Context, node *corev1.Node) error {
+	var objections []string
+
+	for _, name := range r.pluginOrder {
+		plugin, exists := r.plugins[name]
+		if !exists {
+			continue
+		}
+
+		validator, ok := plugin.(Validator)
+		if !ok || !plugin.ShouldRun(node) {
+			continue
+		}
+
+		if err := validator.Validate(ctx, node); err != nil {
+			klog.InfoS("Plugin objected to node deletion", "plugin", name, "node", node.Name, "reason", err.Error())
+			objections = append(objections, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(objections) > 0 {
+		return fmt.Errorf("%s", strings.Join(objections, "; "))
+	}
+	return nil
+}
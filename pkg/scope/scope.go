@@ -0,0 +1,118 @@
+package scope
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Scope decides which nodes carry the cleanup finalizer. The webhook, the
+// watcher's informer handlers and the startup backfill all consult the same
+// Scope so a node is never held by one and released by another.
+type Scope struct {
+	selector           labels.Selector
+	excludeTaints      []filter
+	excludeAnnotations []filter
+}
+
+// filter matches a key, and the value too when one was given ("key=value")
+type filter struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+// New parses the scope configuration. An empty selector selects every node.
+// A node with any of the excluded taints or annotations is out of scope;
+// entries are "key" or "key=value".
+func New(selector string, excludeTaints, excludeAnnotations []string) (*Scope, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node selector %q: %w", selector, err)
+	}
+
+	taints, err := parseFilters(excludeTaints)
+	if err != nil {
+		return nil, fmt.Errorf("invalid excluded taint: %w", err)
+	}
+	annotations, err := parseFilters(excludeAnnotations)
+	if err != nil {
+		return nil, fmt.Errorf("invalid excluded annotation: %w", err)
+	}
+
+	return &Scope{
+		selector:           parsed,
+		excludeTaints:      taints,
+		excludeAnnotations: annotations,
+	}, nil
+}
+
+func parseFilters(entries []string) ([]filter, error) {
+	var filters []filter
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(entry, "=")
+		if key == "" {
+			return nil, fmt.Errorf("%q has no key", entry)
+		}
+		filters = append(filters, filter{key: key, value: value, hasValue: hasValue})
+	}
+	return filters, nil
+}
+
+func (f filter) matches(key, value string) bool {
+	return f.key == key && (!f.hasValue || f.value == value)
+}
+
+// Matches reports whether the node should carry the cleanup finalizer
+func (s *Scope) Matches(node *corev1.Node) bool {
+	return s.Reason(node) == ""
+}
+
+// Reason explains why the node is out of scope, or returns "" if it is in scope
+func (s *Scope) Reason(node *corev1.Node) string {
+	if !s.selector.Matches(labels.Set(node.Labels)) {
+		return fmt.Sprintf("labels do not match selector %q", s.selector.String())
+	}
+
+	for _, f := range s.excludeTaints {
+		for _, taint := range node.Spec.Taints {
+			if f.matches(taint.Key, taint.Value) {
+				return fmt.Sprintf("has excluded taint %s", taint.Key)
+			}
+		}
+	}
+
+	for _, f := range s.excludeAnnotations {
+		for key, value := range node.Annotations {
+			if f.matches(key, value) {
+				return fmt.Sprintf("has excluded annotation %s", key)
+			}
+		}
+	}
+
+	return ""
+}
+
+// String describes the scope for logging
+func (s *Scope) String() string {
+	describe := func(filters []filter) string {
+		entries := make([]string, 0, len(filters))
+		for _, f := range filters {
+			if f.hasValue {
+				entries = append(entries, f.key+"="+f.value)
+			} else {
+				entries = append(entries, f.key)
+			}
+		}
+		return "[" + strings.Join(entries, ",") + "]"
+	}
+
+	return fmt.Sprintf("selector=%q excludeTaints=%s excludeAnnotations=%s",
+		s.selector.String(), describe(s.excludeTaints), describe(s.excludeAnnotations))
+}
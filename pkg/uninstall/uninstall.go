@@ -0,0 +1,204 @@
+package uninstall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// Options configures an uninstall
+type Options struct {
+	// DryRun reports what would change without changing anything
+	DryRun bool
+	// DeleteWebhookConfigs deletes the webhook configurations named
+	// WebhookConfigName before the finalizers are stripped, so new nodes are
+	// not given the finalizer again
+	DeleteWebhookConfigs bool
+	WebhookConfigName    string
+	// RemovalReason is set as the allow-finalizer-removal annotation in the
+	// patch that removes the finalizer when the webhook configurations are
+	// kept, so finalizer protection lets the removal through
+	RemovalReason string
+}
+
+// DefaultRemovalReason is the allow-finalizer-removal reason of an uninstall
+const DefaultRemovalReason = "uninstall"
+
+// Result reports what an uninstall changed, or would change in a dry run
+type Result struct {
+	DeletedWebhookConfigs []string
+	// Nodes whose finalizer was removed
+	ReleasedNodes []string
+	// Subset of ReleasedNodes that were terminating and are deleted now
+	ReleasedTerminatingNodes []string
+	// Nodes whose finalizer could not be removed
+	Failed map[string]error
+}
+
+// Run removes the cleanup finalizer from every node. The webhook Deployment
+// must be stopped first, otherwise its watcher adds the finalizer again.
+func Run(ctx context.Context, client kubernetes.Interface, opts Options) (*Result, error) {
+	result := &Result{Failed: map[string]error{}}
+
+	if opts.DeleteWebhookConfigs {
+		if err := deleteWebhookConfigs(ctx, client, opts, result); err != nil {
+			return result, err
+		}
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return result, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if !hasFinalizer(node) {
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := removeFinalizer(ctx, client, node.Name, opts.removalReason()); err != nil {
+				klog.ErrorS(err, "Failed to remove finalizer", "node", node.Name)
+				result.Failed[node.Name] = err
+				continue
+			}
+		}
+
+		klog.InfoS("Finalizer removed", "node", node.Name, "terminating", node.DeletionTimestamp != nil, "dryRun", opts.DryRun)
+		result.ReleasedNodes = append(result.ReleasedNodes, node.Name)
+		if node.DeletionTimestamp != nil {
+			result.ReleasedTerminatingNodes = append(result.ReleasedTerminatingNodes, node.Name)
+		}
+	}
+
+	return result, nil
+}
+
+// deleteWebhookConfigs deletes the mutating and validating webhook configurations
+func deleteWebhookConfigs(ctx context.Context, client kubernetes.Interface, opts Options, result *Result) error {
+	admission := client.AdmissionregistrationV1()
+	deletions := []struct {
+		kind   string
+		get    func() error
+		delete func() error
+	}{
+		{
+			kind: "MutatingWebhookConfiguration",
+			get: func() error {
+				_, err := admission.MutatingWebhookConfigurations().Get(ctx, opts.WebhookConfigName, metav1.GetOptions{})
+				return err
+			},
+			delete: func() error {
+				return admission.MutatingWebhookConfigurations().Delete(ctx, opts.WebhookConfigName, metav1.DeleteOptions{})
+			},
+		},
+		{
+			kind: "ValidatingWebhookConfiguration",
+			get: func() error {
+				_, err := admission.ValidatingWebhookConfigurations().Get(ctx, opts.WebhookConfigName, metav1.GetOptions{})
+				return err
+			},
+			delete: func() error {
+				return admission.ValidatingWebhookConfigurations().Delete(ctx, opts.WebhookConfigName, metav1.DeleteOptions{})
+			},
+		},
+	}
+
+	for _, d := range deletions {
+		name := d.kind + "/" + opts.WebhookConfigName
+
+		err := d.get()
+		if !opts.DryRun && err == nil {
+			err = d.delete()
+		}
+		if apierrors.IsNotFound(err) {
+			klog.V(2).InfoS("Webhook configuration not found", "config", name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", name, err)
+		}
+
+		klog.InfoS("Webhook configuration deleted", "config", name, "dryRun", opts.DryRun)
+		result.DeletedWebhookConfigs = append(result.DeletedWebhookConfigs, name)
+	}
+	return nil
+}
+
+// removalReason returns the allow-finalizer-removal annotation value for the
+// finalizer patch, or "" when the webhook configurations are deleted first
+// and the annotation is not needed
+func (o Options) removalReason() string {
+	if o.DeleteWebhookConfigs {
+		return ""
+	}
+	if o.RemovalReason == "" {
+		return DefaultRemovalReason
+	}
+	return o.RemovalReason
+}
+
+// removeFinalizer strips the finalizer from the latest version of the node,
+// setting the allow-finalizer-removal annotation to reason in the same patch
+// unless reason is empty. The patch carries the resourceVersion it was
+// computed from, so a concurrent change to the finalizers is a conflict and is
+// retried instead of overwritten.
+func removeFinalizer(ctx context.Context, client kubernetes.Interface, nodeName, reason string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !hasFinalizer(node) {
+			return nil
+		}
+
+		finalizers := []string{}
+		for _, f := range node.Finalizers {
+			if f != constants.FinalizerName {
+				finalizers = append(finalizers, f)
+			}
+		}
+
+		metadata := map[string]interface{}{
+			"resourceVersion": node.ResourceVersion,
+			"finalizers":      finalizers,
+		}
+		if reason != "" {
+			metadata["annotations"] = map[string]string{constants.AllowFinalizerRemovalAnnotation: reason}
+		}
+		patch := map[string]interface{}{"metadata": metadata}
+		patchBytes, err := json.Marshal(patch)
+		if err != nil {
+			return fmt.Errorf("failed to marshal patch: %w", err)
+		}
+
+		_, err = client.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+func hasFinalizer(node *corev1.Node) bool {
+	for _, f := range node.Finalizers {
+		if f == constants.FinalizerName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/approval"
+	"github.com/894/node-cleanup-webhook/pkg/audit"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// awaitApproval parks a node whose cleanup requires manual approval until the
+// approval annotation is set. It returns true while the node must wait.
+//
+// Once the approval timeout passes, cleanup either proceeds unapproved or is
+// escalated with a warning event while the node keeps waiting. The deadline
+// tiers still apply to a waiting node.
+func (w *Watcher) awaitApproval(ctx context.Context, node *corev1.Node) bool {
+	requiredBy := w.approval.RequiredBy(node)
+	if len(requiredBy) == 0 {
+		w.awaiting.Delete(node.Name)
+		return false
+	}
+
+	if approver := approval.Approver(node); approver != "" {
+		w.awaiting.Delete(node.Name)
+		// Retries of an approved cleanup are not announced again
+		if previous, ok := w.approved.Load(node.Name); !ok || previous.(string) != approver {
+			w.approved.Store(node.Name, approver)
+			klog.InfoS("Cleanup approved", "node", node.Name, "approver", approver, "requiredBy", requiredBy)
+			w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonCleanupApproved,
+				"Cleanup approved by %s", approver)
+			w.status.approved(ctx, node, requiredBy, approver)
+			w.audit.Record(audit.Record{Event: audit.EventCleanupApproved, Node: node.Name, NodeUID: string(node.UID),
+				Actor: approver, Reason: "required by " + strings.Join(requiredBy, ", ")})
+		}
+		return false
+	}
+
+	elapsed, timedOut := w.approval.TimedOut(node)
+	if timedOut && w.approval.ProceedOnTimeout() {
+		w.awaiting.Delete(node.Name)
+		if _, ok := w.approved.Load(node.Name); !ok {
+			w.approved.Store(node.Name, "")
+			klog.InfoS("Cleanup approval timed out - proceeding unapproved", "node", node.Name,
+				"elapsed", elapsed.Round(time.Second), "requiredBy", requiredBy)
+			w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonCleanupApprovalOverdue,
+				"Cleanup not approved within %v, proceeding without approval", elapsed.Round(time.Second))
+			w.audit.Record(audit.Record{Event: audit.EventCleanupApproved, Node: node.Name, NodeUID: string(node.UID),
+				Reason: fmt.Sprintf("approval timed out after %v, proceeding unapproved", elapsed.Round(time.Second))})
+		}
+		return false
+	}
+
+	escalated, waiting := w.awaiting.Load(node.Name)
+	if !waiting {
+		w.awaiting.Store(node.Name, false)
+		if stale := approval.StaleApprover(node); stale != "" {
+			klog.InfoS("Ignoring cleanup approval set before the node was deleted", "node", node.Name,
+				"approver", stale, "annotation", constants.CleanupApprovedByAnnotation)
+			w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonCleanupAwaitingApproval,
+				"Approval by %s was set before the node was deleted and does not count; remove %s and set it again",
+				stale, constants.CleanupApprovedByAnnotation)
+		}
+		klog.InfoS("Cleanup awaiting approval", "node", node.Name, "requiredBy", requiredBy,
+			"annotation", constants.CleanupApprovedByAnnotation)
+		w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonCleanupAwaitingApproval,
+			"Cleanup requires approval (%s): set %s=<your username> or POST %s?node=%s",
+			strings.Join(requiredBy, ", "), constants.CleanupApprovedByAnnotation, constants.ApprovalPath, node.Name)
+		w.status.awaitingApproval(ctx, node, requiredBy)
+	}
+
+	if timedOut && (!waiting || !escalated.(bool)) {
+		w.awaiting.Store(node.Name, true)
+		klog.ErrorS(nil, "Cleanup approval overdue - operator attention required", "node", node.Name,
+			"elapsed", elapsed.Round(time.Second), "requiredBy", requiredBy)
+		w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonCleanupApprovalOverdue,
+			"Cleanup still awaiting approval after %v; set %s=<your username> to approve or %s to bypass cleanup",
+			elapsed.Round(time.Second), constants.CleanupApprovedByAnnotation, constants.SkipCleanupAnnotation)
+	}
+	return true
+}
+
+// isAwaitingApproval reports whether the node is parked for approval and
+// nothing changed that the worker needs to act on: no approval or skip
+// annotation, no timeout to escalate or proceed on, approval still required
+func (w *Watcher) isAwaitingApproval(node *corev1.Node) bool {
+	escalated, waiting := w.awaiting.Load(node.Name)
+	if !waiting {
+		return false
+	}
+
+	if approval.Approver(node) != "" || node.Annotations[constants.SkipCleanupAnnotation] == "true" {
+		return false
+	}
+	if _, timedOut := w.approval.TimedOut(node); timedOut && !escalated.(bool) {
+		return false
+	}
+	return len(w.approval.RequiredBy(node)) > 0
+}
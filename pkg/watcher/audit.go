@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/audit"
+	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// auditObserver writes an audit record for each plugin outcome
+type auditObserver struct {
+	w *Watcher
+}
+
+func (a auditObserver) record(node *corev1.Node, event, name, outcome string, err error) {
+	record := audit.Record{Event: event, Node: node.Name, NodeUID: string(node.UID), Plugin: name, Outcome: outcome}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if started, ok := a.w.pluginStarts.LoadAndDelete(node.Name + "/" + name); ok && event == audit.EventPluginFinished {
+		record.DurationSeconds = time.Since(started.(time.Time)).Seconds()
+	}
+	a.w.audit.Record(record)
+}
+
+// PluginStarted implements plugins.Observer
+func (a auditObserver) PluginStarted(ctx context.Context, node *corev1.Node, name string) {
+	a.w.pluginStarts.Store(node.Name+"/"+name, time.Now())
+	a.w.audit.Record(audit.Record{Event: audit.EventPluginStarted, Node: node.Name, NodeUID: string(node.UID), Plugin: name})
+}
+
+// PluginSucceeded implements plugins.Observer
+func (a auditObserver) PluginSucceeded(ctx context.Context, node *corev1.Node, name string) {
+	a.record(node, audit.EventPluginFinished, name, audit.OutcomeSucceeded, nil)
+}
+
+// PluginFailed implements plugins.Observer
+func (a auditObserver) PluginFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	outcome := audit.OutcomeFailed
+	if plugins.IsTimeout(err) {
+		outcome = audit.OutcomeTimedOut
+	}
+	a.record(node, audit.EventPluginFinished, name, outcome, err)
+}
+
+// PluginSkipped implements plugins.Observer
+func (a auditObserver) PluginSkipped(ctx context.Context, node *corev1.Node, name string, reason string) {
+	a.w.audit.Record(audit.Record{Event: audit.EventPluginFinished, Node: node.Name, NodeUID: string(node.UID),
+		Plugin: name, Outcome: audit.OutcomeSkipped, Reason: reason})
+}
+
+// PluginRolledBack implements plugins.Observer
+func (a auditObserver) PluginRolledBack(ctx context.Context, node *corev1.Node, name string) {
+	a.record(node, audit.EventPluginRolledBack, name, audit.OutcomeSucceeded, nil)
+}
+
+// PluginRollbackFailed implements plugins.Observer
+func (a auditObserver) PluginRollbackFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	a.record(node, audit.EventPluginRolledBack, name, audit.OutcomeFailed, err)
+}
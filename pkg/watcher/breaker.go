@@ -0,0 +1,388 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/audit"
+	"github.com/894/node-cleanup-webhook/pkg/config"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// Keys of the circuit breaker ConfigMap
+const (
+	breakerTrippedKey   = "tripped"
+	breakerReasonKey    = "reason"
+	breakerTrippedAtKey = "trippedAt"
+	breakerResetByKey   = "resetBy"
+	breakerResetAtKey   = "resetAt"
+)
+
+// breakerLimits are the thresholds that trip the circuit breaker; 0 disables
+// a limit
+type breakerLimits struct {
+	maxDeletions        int           // Nodes entering deletion within window
+	maxDeletionsPercent int           // Of the nodes with the finalizer, within window
+	window              time.Duration // Sliding window for the deletion limits
+	maxCleanupsPerHour  int           // Cleanups started within the last hour
+}
+
+func (l breakerLimits) enabled() bool {
+	return l.maxDeletions > 0 || l.maxDeletionsPercent > 0 || l.maxCleanupsPerHour > 0
+}
+
+// breaker pauses new cleanups when nodes are deleted faster than any sane
+// rollout would, e.g. by automation gone wrong. Once tripped it holds the
+// finalizers of nodes whose cleanup did not start yet until an operator
+// resets it; cleanups already running still finish and are retried.
+//
+// The tripped state is kept in a ConfigMap so it survives restarts and
+// leader changes. Setting the ResetBreakerAnnotation on it resets the
+// breaker; setting its "tripped" key to "true" pauses cleanups by hand, even
+// when no limit is configured.
+type breaker struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	limits    breakerLimits
+
+	mu sync.Mutex
+	// DeletionTimestamp per node that entered deletion within the window
+	deletions map[string]time.Time
+	// Start times of cleanups within the last hour
+	starts []time.Time
+	// Nodes whose cleanup started; their retries are not counted or paused
+	admitted  map[string]bool
+	tripped   bool
+	reason    string
+	trippedAt time.Time
+	// Whether the ConfigMap records the trip; until it does, sync retries
+	// saving it so a restart or leader change does not lose it
+	persisted bool
+	// Deletions before the last reset do not count
+	resetAt time.Time
+}
+
+func newBreaker(client kubernetes.Interface, cfg *config.Config) *breaker {
+	return &breaker{
+		client:    client,
+		namespace: cfg.Namespace,
+		name:      cfg.BreakerConfigMapName,
+		limits: breakerLimits{
+			maxDeletions:        cfg.BreakerMaxDeletions,
+			maxDeletionsPercent: cfg.BreakerMaxDeletionsPercent,
+			window:              cfg.BreakerWindow,
+			maxCleanupsPerHour:  cfg.BreakerMaxCleanupsPerHour,
+		},
+		deletions: make(map[string]time.Time),
+		admitted:  make(map[string]bool),
+	}
+}
+
+// observeDeletion counts a node entering deletion and trips the breaker when
+// too many did within the window. total returns the number of nodes with the
+// finalizer, for the percentage limit.
+func (b *breaker) observeDeletion(node *corev1.Node, total func() (int, int)) {
+	if !b.limits.enabled() || b.limits.window <= 0 {
+		return
+	}
+	deletedAt := node.DeletionTimestamp.Time
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, seen := b.deletions[node.Name]; seen || b.tripped || deletedAt.Before(b.resetAt) {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(deletedAt) > b.limits.window {
+		return
+	}
+	b.deletions[node.Name] = deletedAt
+	for name, at := range b.deletions {
+		if now.Sub(at) > b.limits.window {
+			delete(b.deletions, name)
+		}
+	}
+
+	count := len(b.deletions)
+	if b.limits.maxDeletions > 0 && count > b.limits.maxDeletions {
+		b.trip(metrics.BreakerReasonDeletions, fmt.Sprintf("%d nodes entered deletion within %v (limit %d)",
+			count, b.limits.window, b.limits.maxDeletions))
+		return
+	}
+	if b.limits.maxDeletionsPercent > 0 {
+		withFinalizer, _ := total()
+		if withFinalizer > 0 && count*100 > b.limits.maxDeletionsPercent*withFinalizer {
+			b.trip(metrics.BreakerReasonDeletions, fmt.Sprintf("%d of %d nodes entered deletion within %v (limit %d%%)",
+				count, withFinalizer, b.limits.window, b.limits.maxDeletionsPercent))
+		}
+	}
+}
+
+// allowCleanup reports whether the node's cleanup may start, counting it
+// against the hourly budget. If not, it returns why.
+func (b *breaker) allowCleanup(nodeName string) (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.admitted[nodeName] {
+		return true, ""
+	}
+	if b.tripped {
+		return false, b.reason
+	}
+
+	if b.limits.maxCleanupsPerHour > 0 {
+		cutoff := time.Now().Add(-time.Hour)
+		recent := b.starts[:0]
+		for _, start := range b.starts {
+			if start.After(cutoff) {
+				recent = append(recent, start)
+			}
+		}
+		b.starts = recent
+		if len(b.starts) >= b.limits.maxCleanupsPerHour {
+			b.trip(metrics.BreakerReasonRate, fmt.Sprintf("%d cleanups started within the last hour (budget %d)",
+				len(b.starts), b.limits.maxCleanupsPerHour))
+			return false, b.reason
+		}
+		b.starts = append(b.starts, time.Now())
+	}
+
+	b.admitted[nodeName] = true
+	return true, ""
+}
+
+// isTripped reports whether new cleanups are paused
+func (b *breaker) isTripped() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tripped
+}
+
+// forget drops a node that is gone. Its deletion keeps counting until it
+// leaves the window.
+func (b *breaker) forget(nodeName string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.admitted, nodeName)
+}
+
+// trip pauses new cleanups and records why; b.mu must be held
+func (b *breaker) trip(label, reason string) {
+	b.tripped, b.reason = true, reason
+	metrics.BreakerTripsTotal.WithLabelValues(label).Inc()
+	metrics.BreakerTripped.Set(1)
+	klog.ErrorS(nil, "Circuit breaker tripped - new cleanups paused until reset", "reason", reason,
+		"configMap", b.namespace+"/"+b.name, "annotation", constants.ResetBreakerAnnotation)
+
+	// Persisted in the background: trip runs in informer handlers. A failed
+	// write is retried by sync.
+	b.trippedAt, b.persisted = time.Now(), false
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.FinalizerOperationTimeout)
+		defer cancel()
+		if err := b.persist(ctx); err != nil {
+			klog.ErrorS(err, "Failed to persist circuit breaker state - will retry", "configMap", b.namespace+"/"+b.name)
+		}
+	}()
+}
+
+// persist saves a trip the ConfigMap does not record yet. sync ignores the
+// write if it lands after a reset, see staleTrip.
+func (b *breaker) persist(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.tripped || b.persisted {
+		b.mu.Unlock()
+		return nil
+	}
+	trippedAt := b.trippedAt
+	data := map[string]string{
+		breakerTrippedKey:   "true",
+		breakerReasonKey:    b.reason,
+		breakerTrippedAtKey: trippedAt.UTC().Format(time.RFC3339Nano),
+	}
+	b.mu.Unlock()
+
+	if err := b.save(ctx, data, false); err != nil {
+		return fmt.Errorf("failed to persist circuit breaker trip: %w", err)
+	}
+
+	b.mu.Lock()
+	if b.tripped && b.trippedAt.Equal(trippedAt) {
+		b.persisted = true
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// sync reads the ConfigMap: a reset annotation resets the breaker, a tripped
+// state set elsewhere (by a previous leader or by hand) is adopted, and a trip
+// of this replica the ConfigMap lacks is saved again. It returns true when the
+// breaker was reset.
+func (b *breaker) sync(ctx context.Context) (bool, error) {
+	cm, err := b.client.CoreV1().ConfigMaps(b.namespace).Get(ctx, b.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, b.persist(ctx)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get circuit breaker configmap: %w", err)
+	}
+
+	if resetBy, ok := cm.Annotations[constants.ResetBreakerAnnotation]; ok {
+		now := time.Now()
+		// Clearing trippedAt leaves a later manual trip without one, which
+		// staleTrip never ignores
+		if err := b.save(ctx, map[string]string{
+			breakerTrippedKey:   "false",
+			breakerReasonKey:    "",
+			breakerTrippedAtKey: "",
+			breakerResetByKey:   resetBy,
+			breakerResetAtKey:   now.UTC().Format(time.RFC3339Nano),
+		}, true); err != nil {
+			return false, err
+		}
+
+		b.mu.Lock()
+		wasTripped, reason := b.tripped, b.reason
+		b.tripped, b.reason, b.persisted, b.resetAt = false, "", false, now
+		b.deletions = make(map[string]time.Time)
+		b.starts = nil
+		b.mu.Unlock()
+
+		metrics.BreakerTripped.Set(0)
+		klog.InfoS("Circuit breaker reset - resuming cleanups", "resetBy", resetBy,
+			"wasTripped", wasTripped, "reason", reason)
+		return true, nil
+	}
+
+	if cm.Data[breakerTrippedKey] == "true" && staleTrip(cm.Data) {
+		klog.V(2).InfoS("Ignoring circuit breaker trip recorded before the last reset",
+			"trippedAt", cm.Data[breakerTrippedAtKey], "resetAt", cm.Data[breakerResetAtKey])
+	} else if cm.Data[breakerTrippedKey] == "true" {
+		b.mu.Lock()
+		if !b.tripped {
+			b.tripped, b.reason = true, cm.Data[breakerReasonKey]
+			if b.reason == "" {
+				b.reason = "tripped in configmap " + b.namespace + "/" + b.name
+			}
+			metrics.BreakerTripped.Set(1)
+			klog.ErrorS(nil, "Circuit breaker tripped - new cleanups paused until reset", "reason", b.reason,
+				"trippedAt", cm.Data[breakerTrippedAtKey], "configMap", b.namespace+"/"+b.name)
+		}
+		// The ConfigMap records a trip, so a restart keeps the breaker tripped
+		b.persisted = true
+		b.mu.Unlock()
+	}
+	return false, b.persist(ctx)
+}
+
+// staleTrip reports whether the ConfigMap's trip predates its last reset, as
+// when a trip persisted in the background lands after the reset
+func staleTrip(data map[string]string) bool {
+	trippedAt, err := time.Parse(time.RFC3339Nano, data[breakerTrippedAtKey])
+	if err != nil {
+		return false
+	}
+	resetAt, err := time.Parse(time.RFC3339Nano, data[breakerResetAtKey])
+	return err == nil && !trippedAt.After(resetAt)
+}
+
+// save merges data into the ConfigMap, creating it if needed. clearReset
+// removes the reset annotation in the same write.
+func (b *breaker) save(ctx context.Context, data map[string]string, clearReset bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := b.client.CoreV1().ConfigMaps(b.namespace).Get(ctx, b.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = b.client.CoreV1().ConfigMaps(b.namespace).Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: b.name, Namespace: b.namespace},
+				Data:       data,
+			}, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if cm.Data == nil {
+			cm.Data = make(map[string]string, len(data))
+		}
+		for key, value := range data {
+			cm.Data[key] = value
+		}
+		if clearReset {
+			delete(cm.Annotations, constants.ResetBreakerAnnotation)
+		}
+		_, err = b.client.CoreV1().ConfigMaps(b.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// run syncs the breaker with its ConfigMap every BreakerSyncInterval until
+// ctx is done, calling onReset after each reset. Each sync retries saving a
+// trip that is not persisted yet.
+func (b *breaker) run(ctx context.Context, onReset func()) {
+	ticker := time.NewTicker(constants.BreakerSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reset, err := b.sync(ctx)
+		if err != nil {
+			klog.ErrorS(err, "Failed to sync circuit breaker", "configMap", b.namespace+"/"+b.name)
+		} else if reset {
+			onReset()
+		}
+	}
+}
+
+// pauseForBreaker holds a node's cleanup while the circuit breaker is
+// tripped. It returns true when the cleanup must not start.
+func (w *Watcher) pauseForBreaker(node *corev1.Node) bool {
+	allowed, reason := w.breaker.allowCleanup(node.Name)
+	if allowed {
+		w.paused.Delete(node.Name)
+		return false
+	}
+
+	// Announce each pause once; a reset requeues the node
+	if _, wasPaused := w.paused.LoadOrStore(node.Name, true); !wasPaused {
+		klog.InfoS("Cleanup paused by the circuit breaker", "node", node.Name, "reason", reason)
+		w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonCleanupPaused,
+			"Cleanup paused, circuit breaker tripped (%s): reset with %s on configmap %s/%s or POST %s",
+			reason, constants.ResetBreakerAnnotation, w.breaker.namespace, w.breaker.name, constants.BreakerResetPath)
+		w.audit.Record(audit.Record{Event: audit.EventCleanupPaused, Node: node.Name, NodeUID: string(node.UID), Reason: reason})
+	}
+	return true
+}
+
+// isPaused reports whether the node's cleanup is held by the tripped circuit
+// breaker. The skip annotation is acted on at once.
+func (w *Watcher) isPaused(node *corev1.Node) bool {
+	if _, paused := w.paused.Load(node.Name); !paused || node.Annotations[constants.SkipCleanupAnnotation] == "true" {
+		return false
+	}
+	return w.breaker.isTripped()
+}
+
+// resumePaused requeues the nodes held by the circuit breaker after a reset
+func (w *Watcher) resumePaused() {
+	w.paused.Range(func(key, _ interface{}) bool {
+		w.paused.Delete(key)
+		w.queue.Add(key)
+		return true
+	})
+}
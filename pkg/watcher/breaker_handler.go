@@ -0,0 +1,98 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/894/node-cleanup-webhook/pkg/adminapi"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// BreakerResetHandler serves POST /reset-breaker, an alternative to setting
+// the reset annotation on the circuit breaker ConfigMap by hand. The caller
+// authenticates with a Kubernetes bearer token and needs update on
+// nodecleanups/breaker. Any replica can serve it; the leader's watcher picks
+// up the annotation.
+type BreakerResetHandler struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewBreakerResetHandler creates the circuit breaker reset API handler for
+// the ConfigMap namespace/name
+func NewBreakerResetHandler(client kubernetes.Interface, namespace, name string) *BreakerResetHandler {
+	return &BreakerResetHandler{client: client, namespace: namespace, name: name}
+}
+
+// ServeHTTP implements http.Handler
+func (h *BreakerResetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	username, ok := adminapi.Authorize(w, r, h.client, authorizationv1.ResourceAttributes{
+		Verb:        "update",
+		Group:       constants.ApprovalGroup,
+		Resource:    constants.ApprovalResource,
+		Subresource: constants.BreakerSubresource,
+	})
+	if !ok {
+		return
+	}
+
+	// The reset is requested whatever the ConfigMap says: the leader may be
+	// tripped in memory with the trip not persisted yet. Its sync decides
+	// whether anything was tripped.
+	if err := h.requestReset(r.Context(), username); err != nil {
+		klog.ErrorS(err, "Failed to request circuit breaker reset", "user", username)
+		http.Error(w, "failed to request reset", http.StatusInternalServerError)
+		return
+	}
+
+	klog.InfoS("Circuit breaker reset requested through API", "user", username)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "circuit breaker reset requested by %s, cleanups resume within %v\n", username, constants.BreakerSyncInterval)
+}
+
+// requestReset sets the reset annotation for the watcher to act on, creating
+// the ConfigMap if it does not exist
+func (h *BreakerResetHandler) requestReset(ctx context.Context, username string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				constants.ResetBreakerAnnotation: username,
+			},
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	configMaps := h.client.CoreV1().ConfigMaps(h.namespace)
+	_, err = configMaps.Patch(ctx, h.name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        h.name,
+			Namespace:   h.namespace,
+			Annotations: map[string]string{constants.ResetBreakerAnnotation: username},
+		},
+	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// Created concurrently, e.g. by the leader persisting a trip
+		_, err = configMaps.Patch(ctx, h.name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	}
+	return err
+}
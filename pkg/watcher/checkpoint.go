@@ -0,0 +1,137 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// cleanupProgress is the value stored in the cleanup progress annotation
+type cleanupProgress struct {
+	UID       types.UID `json:"uid"`
+	Completed []string  `json:"completed"`
+}
+
+// nodeCheckpoint persists plugin progress in an annotation on the node itself,
+// so it survives retries, controller restarts and leader changes
+type nodeCheckpoint struct {
+	client   kubernetes.Interface
+	nodeName string
+
+	mu       sync.Mutex
+	progress cleanupProgress
+}
+
+// newNodeCheckpoint loads the progress recorded on the node. Progress recorded
+// for a different UID (a previous node with the same name) is discarded.
+func newNodeCheckpoint(client kubernetes.Interface, node *corev1.Node) *nodeCheckpoint {
+	cp := &nodeCheckpoint{
+		client:   client,
+		nodeName: node.Name,
+		progress: cleanupProgress{UID: node.UID},
+	}
+
+	raw, ok := node.Annotations[constants.CleanupProgressAnnotation]
+	if !ok {
+		return cp
+	}
+
+	var recorded cleanupProgress
+	if err := json.Unmarshal([]byte(raw), &recorded); err != nil {
+		klog.ErrorS(err, "Ignoring unreadable cleanup progress", "node", node.Name, "annotation", constants.CleanupProgressAnnotation)
+		return cp
+	}
+	if recorded.UID != node.UID {
+		klog.InfoS("Ignoring cleanup progress recorded for a different node UID", "node", node.Name,
+			"recordedUID", recorded.UID, "uid", node.UID)
+		return cp
+	}
+
+	cp.progress.Completed = recorded.Completed
+	if len(recorded.Completed) > 0 {
+		klog.InfoS("Resuming cleanup from checkpoint", "node", node.Name, "completedPlugins", recorded.Completed)
+	}
+	return cp
+}
+
+// Completed reports whether the plugin already succeeded for this node
+func (c *nodeCheckpoint) Completed(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, completed := range c.progress.Completed {
+		if completed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkCompleted records the plugin as completed and patches the annotation
+func (c *nodeCheckpoint) MarkCompleted(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.progress.Completed = append(c.progress.Completed, name)
+	return c.persist(ctx)
+}
+
+// CompletedPlugins returns the completed plugins in completion order
+func (c *nodeCheckpoint) CompletedPlugins() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.progress.Completed...)
+}
+
+// MarkRolledBack removes the plugin from the completed plugins and patches the annotation
+func (c *nodeCheckpoint) MarkRolledBack(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var remaining []string
+	for _, completed := range c.progress.Completed {
+		if completed != name {
+			remaining = append(remaining, completed)
+		}
+	}
+	c.progress.Completed = remaining
+	return c.persist(ctx)
+}
+
+// persist writes the current progress to the node; callers hold c.mu
+func (c *nodeCheckpoint) persist(ctx context.Context) error {
+	value, err := json.Marshal(c.progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cleanup progress: %w", err)
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				constants.CleanupProgressAnnotation: string(value),
+			},
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	_, err = c.client.CoreV1().Nodes().Patch(ctx, c.nodeName, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch cleanup progress: %w", err)
+	}
+
+	klog.V(2).InfoS("Cleanup progress saved", "node", c.nodeName, "completedPlugins", c.progress.Completed)
+	return nil
+}
@@ -0,0 +1,192 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/audit"
+	"github.com/894/node-cleanup-webhook/pkg/config"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// escalationTier is how far past its deadline a node's cleanup has run
+type escalationTier int
+
+const (
+	tierNone escalationTier = iota
+	tierWarn
+	tierAlert
+	tierForceRelease
+)
+
+func (t escalationTier) String() string {
+	switch t {
+	case tierWarn:
+		return "warn"
+	case tierAlert:
+		return "alert"
+	case tierForceRelease:
+		return "force-release"
+	default:
+		return "none"
+	}
+}
+
+// deadlines are the escalation tiers measured from DeletionTimestamp.
+// A zero duration disables the tier.
+type deadlines struct {
+	warnAfter         time.Duration
+	alertAfter        time.Duration
+	forceReleaseAfter time.Duration
+}
+
+func deadlinesFromConfig(cfg *config.Config) deadlines {
+	return deadlines{
+		warnAfter:         cfg.CleanupWarnAfter,
+		alertAfter:        cfg.CleanupAlertAfter,
+		forceReleaseAfter: cfg.CleanupForceReleaseAfter,
+	}
+}
+
+// forNode applies the node's deadline annotations over the global tiers.
+// Unparseable annotations are ignored.
+func (d deadlines) forNode(node *corev1.Node) deadlines {
+	override := func(annotation string, value time.Duration) time.Duration {
+		raw, ok := node.Annotations[annotation]
+		if !ok {
+			return value
+		}
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			klog.ErrorS(err, "Ignoring invalid cleanup deadline annotation", "node", node.Name,
+				"annotation", annotation, "value", raw)
+			return value
+		}
+		return parsed
+	}
+
+	return deadlines{
+		warnAfter:         override(constants.CleanupWarnAfterAnnotation, d.warnAfter),
+		alertAfter:        override(constants.CleanupAlertAfterAnnotation, d.alertAfter),
+		forceReleaseAfter: override(constants.CleanupForceReleaseAfterAnnotation, d.forceReleaseAfter),
+	}
+}
+
+// tier returns the highest tier reached after the node has been terminating for elapsed
+func (d deadlines) tier(elapsed time.Duration) escalationTier {
+	reached := func(after time.Duration) bool {
+		return after > 0 && elapsed >= after
+	}
+
+	switch {
+	case reached(d.forceReleaseAfter):
+		return tierForceRelease
+	case reached(d.alertAfter):
+		return tierAlert
+	case reached(d.warnAfter):
+		return tierWarn
+	default:
+		return tierNone
+	}
+}
+
+// checkDeadline escalates a terminating node whose cleanup is taking too long.
+// It runs on every informer event, including resyncs, so tiers are reached
+// while the node is in flight, backing off or parked after exhausting retries.
+// It returns true once the finalizer is due to be force-released; the worker
+// does that (see forceReleaseDue), never while a cleanup is running.
+func (w *Watcher) checkDeadline(node *corev1.Node) bool {
+	elapsed := time.Since(node.DeletionTimestamp.Time)
+	tier := w.deadlines.forNode(node).tier(elapsed)
+	if tier == tierNone {
+		return false
+	}
+
+	// Force release also applies to dry-run nodes: it bounds how long a node
+	// stays Terminating and is no part of the cleanup that dry run plans
+
+	// Each tier fires once per node while this replica runs the watcher
+	previous, _ := w.escalated.Load(node.Name)
+	if previous != nil && previous.(escalationTier) >= tier {
+		return tier == tierForceRelease
+	}
+	w.escalated.Store(node.Name, tier)
+	metrics.CleanupEscalationsTotal.WithLabelValues(tier.String()).Inc()
+
+	switch tier {
+	case tierWarn:
+		klog.InfoS("Cleanup is taking longer than expected", "node", node.Name, "elapsed", elapsed.Round(time.Second))
+		w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonCleanupDeadlineWarning,
+			"Node has been waiting for cleanup for %v", elapsed.Round(time.Second))
+
+	case tierAlert:
+		klog.ErrorS(nil, "Cleanup overdue - operator attention required", "node", node.Name,
+			"elapsed", elapsed.Round(time.Second), "annotation", constants.CleanupOverdueAnnotation)
+		w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonCleanupOverdue,
+			"Cleanup overdue after %v; set %s to bypass it", elapsed.Round(time.Second), constants.SkipCleanupAnnotation)
+		if _, flagged := node.Annotations[constants.CleanupOverdueAnnotation]; !flagged {
+			go func() {
+				if err := w.markOverdue(w.ctx, node.Name); err != nil {
+					klog.ErrorS(err, "Failed to mark node cleanup overdue", "node", node.Name)
+				}
+			}()
+		}
+
+	case tierForceRelease:
+		klog.ErrorS(nil, "Cleanup deadline exceeded - force-releasing finalizer", "node", node.Name,
+			"elapsed", elapsed.Round(time.Second))
+	}
+
+	return tier == tierForceRelease
+}
+
+// forceReleaseDue reports whether the node is past its force-release deadline
+// and how long it has been terminating, dry run or not
+func (w *Watcher) forceReleaseDue(node *corev1.Node) (time.Duration, bool) {
+	elapsed := time.Since(node.DeletionTimestamp.Time)
+	return elapsed, w.deadlines.forNode(node).tier(elapsed) == tierForceRelease
+}
+
+// forceRelease removes the finalizer without completing cleanup. A failure
+// is retried on the next informer event, which enqueues the node again.
+func (w *Watcher) forceRelease(ctx context.Context, node *corev1.Node, elapsed time.Duration) {
+	reason := fmt.Sprintf("deadline exceeded after %v", elapsed.Round(time.Second))
+	if err := w.removeFinalizer(ctx, node, reason); err != nil {
+		klog.ErrorS(err, "Failed to force-release finalizer - will retry", "node", node.Name)
+		return
+	}
+
+	w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonFinalizerForceReleased,
+		"Finalizer %s force-released after %v without completing cleanup", constants.FinalizerName, elapsed.Round(time.Second))
+	w.status.forceReleased(ctx, node.Name, elapsed)
+	w.audit.Record(audit.Record{Event: audit.EventFinalizerForceReleased, Node: node.Name, NodeUID: string(node.UID), Reason: reason})
+}
+
+// markOverdue sets the overdue annotation so alerting can select stuck nodes
+func (w *Watcher) markOverdue(ctx context.Context, nodeName string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				constants.CleanupOverdueAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	_, err = w.client.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch node: %w", err)
+	}
+	return nil
+}
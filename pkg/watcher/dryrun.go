@@ -0,0 +1,99 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/metrics"
+	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// isDryRun reports whether the node's cleanup must only be planned, either
+// globally or through the node's dry-run annotation
+func (w *Watcher) isDryRun(node *corev1.Node) bool {
+	return w.dryRun || node.Annotations[constants.DryRunAnnotation] == "true"
+}
+
+// alreadyPlanned reports whether the plan was recorded for this version of the
+// node, so informer resyncs do not record it again
+func (w *Watcher) alreadyPlanned(node *corev1.Node) bool {
+	version, ok := w.planned.Load(node.Name)
+	return ok && version.(string) == node.ResourceVersion
+}
+
+// planIfDeletedInDryRun plans the cleanup of a dry-run node in scope that was
+// removed without ever being seen terminating: dry run adds no finalizer, so a
+// node without other finalizers is removed at once. The plan is recorded from
+// the informer's last copy of the node.
+func (w *Watcher) planIfDeletedInDryRun(node *corev1.Node) {
+	if containsFinalizer(node.Finalizers, constants.FinalizerName) || !w.isDryRun(node) || w.scope.Reason(node) != "" {
+		return
+	}
+
+	node = node.DeepCopy()
+	if node.DeletionTimestamp == nil {
+		now := metav1.Now()
+		node.DeletionTimestamp = &now
+	}
+	go func() {
+		if err := w.planCleanup(w.ctx, node); err != nil {
+			klog.ErrorS(err, "Dry run: failed to plan cleanup of deleted node", "node", node.Name)
+		}
+		w.planned.Delete(node.Name)
+	}()
+}
+
+// planCleanup records which plugins would run for the node and what they would
+// do, without running any Cleanup or touching the finalizer
+func (w *Watcher) planCleanup(ctx context.Context, node *corev1.Node) error {
+	plan, err := w.pluginRegistry.PlanAll(ctx, node, newNodeCheckpoint(w.client, node))
+	if err != nil {
+		return fmt.Errorf("failed to plan cleanup: %w", err)
+	}
+
+	var wouldRun, wouldSkip []string
+	for _, entry := range plan {
+		if !entry.WouldRun {
+			klog.InfoS("Dry run: plugin would be skipped", "node", node.Name, "plugin", entry.Name, "reason", entry.SkipReason)
+			wouldSkip = append(wouldSkip, entry.Name)
+			continue
+		}
+		klog.InfoS("Dry run: plugin would run", "node", node.Name, "plugin", entry.Name,
+			"after", entry.After, "actions", entry.Actions, "planError", entry.PlanErr)
+		wouldRun = append(wouldRun, entry.Name)
+	}
+
+	klog.InfoS("Dry run: cleanup planned - finalizer left in place", "node", node.Name,
+		"wouldRun", wouldRun, "wouldSkip", wouldSkip)
+	w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonCleanupPlanned,
+		"Dry run: would run plugins [%s], skip [%s]; finalizer left in place",
+		strings.Join(wouldRun, ", "), strings.Join(wouldSkip, ", "))
+	w.status.planned(ctx, node, plan)
+	metrics.CleanupAttemptsTotal.WithLabelValues(metrics.ResultDryRun).Inc()
+
+	w.planned.Store(node.Name, node.ResourceVersion)
+	return nil
+}
+
+// planMessage describes a planned plugin for the NodeCleanup status
+func planMessage(entry plugins.PlannedPlugin) string {
+	var message string
+	switch {
+	case entry.PlanErr != nil:
+		message = fmt.Sprintf("dry run: plan failed: %v", entry.PlanErr)
+	case len(entry.Actions) == 0:
+		message = "dry run: would run cleanup"
+	default:
+		message = "dry run: " + strings.Join(entry.Actions, "; ")
+	}
+
+	if len(entry.After) > 0 {
+		message += fmt.Sprintf(" (after %s)", strings.Join(entry.After, ", "))
+	}
+	return message
+}
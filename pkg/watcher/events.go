@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"context"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+// eventObserver records a Kubernetes Event against the Node for each plugin outcome
+type eventObserver struct {
+	recorder record.EventRecorder
+}
+
+// PluginStarted implements plugins.Observer
+func (e eventObserver) PluginStarted(ctx context.Context, node *corev1.Node, name string) {
+	e.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonPluginStarted, "Cleanup plugin %s started", name)
+}
+
+// PluginSucceeded implements plugins.Observer
+func (e eventObserver) PluginSucceeded(ctx context.Context, node *corev1.Node, name string) {
+	e.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonPluginSucceeded, "Cleanup plugin %s succeeded", name)
+}
+
+// PluginFailed implements plugins.Observer
+func (e eventObserver) PluginFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	if plugins.IsTimeout(err) {
+		e.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonPluginTimedOut, "Cleanup plugin %s timed out: %v", name, err)
+		return
+	}
+	e.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonPluginFailed, "Cleanup plugin %s failed: %v", name, err)
+}
+
+// PluginSkipped implements plugins.Observer
+func (e eventObserver) PluginSkipped(ctx context.Context, node *corev1.Node, name string, reason string) {
+	e.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonPluginSkipped, "Cleanup plugin %s skipped: %s", name, reason)
+}
+
+// PluginRolledBack implements plugins.Observer
+func (e eventObserver) PluginRolledBack(ctx context.Context, node *corev1.Node, name string) {
+	e.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonPluginRolledBack, "Cleanup plugin %s rolled back", name)
+}
+
+// PluginRollbackFailed implements plugins.Observer
+func (e eventObserver) PluginRollbackFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	e.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonPluginRollbackFailed, "Cleanup plugin %s rollback failed: %v", name, err)
+}
+
+// nodeRef references a node by name for events recorded without the object at
+// hand. Like the kubelet it uses the name as UID, which kubectl describe node
+// also matches.
+func nodeRef(nodeName string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Node",
+		Name:       nodeName,
+		UID:        types.UID(nodeName),
+	}
+}
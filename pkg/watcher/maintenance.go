@@ -0,0 +1,65 @@
+package watcher
+
+import (
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/audit"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// deferToWindow keeps a node's cleanup from starting outside the maintenance
+// schedule. The node keeps its finalizer and is requeued for when the next
+// window opens. It returns true when the cleanup was deferred.
+//
+// A cleanup already running when a window closes is not interrupted.
+func (w *Watcher) deferToWindow(node *corev1.Node) bool {
+	now := time.Now()
+	reason := w.schedule.Reason(now)
+	if reason == "" {
+		w.deferred.Delete(node.Name)
+		return false
+	}
+
+	delay := constants.MaintenanceRecheckInterval
+	opensAt, found := w.schedule.NextOpen(now)
+	if found {
+		delay = opensAt.Sub(now)
+	}
+
+	// Announce each deferral once, not on every recheck
+	previous, wasDeferred := w.deferred.Load(node.Name)
+	w.deferred.Store(node.Name, now.Add(delay))
+	if !wasDeferred || !now.Before(previous.(time.Time)) {
+		record := audit.Record{Event: audit.EventCleanupDeferred, Node: node.Name, NodeUID: string(node.UID), Reason: reason}
+		if found {
+			record.Reason += ", until " + opensAt.Format(time.RFC3339)
+		}
+		w.audit.Record(record)
+		if found {
+			klog.InfoS("Cleanup deferred until the maintenance window opens", "node", node.Name,
+				"reason", reason, "opensAt", opensAt.Format(time.RFC3339))
+			w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonCleanupDeferred,
+				"Cleanup deferred (%s) until %s", reason, opensAt.Format(time.RFC3339))
+		} else {
+			klog.ErrorS(nil, "Cleanup deferred - no maintenance window opens within a year", "node", node.Name,
+				"reason", reason, "schedule", w.schedule.String())
+			w.recorder.Eventf(node, corev1.EventTypeWarning, constants.ReasonCleanupDeferred,
+				"Cleanup deferred (%s) and no maintenance window opens within a year", reason)
+		}
+	}
+
+	w.queue.AddAfter(node.Name, delay)
+	return true
+}
+
+// isDeferred reports whether the node's cleanup is waiting for a maintenance
+// window that has not opened yet. The skip annotation is acted on at once.
+func (w *Watcher) isDeferred(node *corev1.Node) bool {
+	until, deferred := w.deferred.Load(node.Name)
+	if !deferred || node.Annotations[constants.SkipCleanupAnnotation] == "true" {
+		return false
+	}
+	return time.Now().Before(until.(time.Time))
+}
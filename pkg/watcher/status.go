@@ -0,0 +1,336 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/apis/generated/clientset/versioned"
+	infrav1alpha1 "github.com/894/node-cleanup-webhook/pkg/apis/infra/v1alpha1"
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// statusRecorder keeps the NodeCleanup resource of each node current.
+// Recording is best effort: failures are logged and never fail a cleanup.
+type statusRecorder struct {
+	client versioned.Interface
+	// Records of deleted nodes are pruned this long after their last
+	// activity; 0 keeps them
+	retention time.Duration
+}
+
+func newStatusRecorder(client versioned.Interface, retention time.Duration) *statusRecorder {
+	return &statusRecorder{client: client, retention: retention}
+}
+
+// ensure creates the NodeCleanup for a node that entered deletion. A record
+// left over from a previous node with the same name is reset.
+func (s *statusRecorder) ensure(ctx context.Context, node *corev1.Node) {
+	spec := infrav1alpha1.NodeCleanupSpec{
+		NodeName:          node.Name,
+		NodeUID:           node.UID,
+		DeletionTimestamp: *node.DeletionTimestamp,
+	}
+
+	cleanups := s.client.InfraV1alpha1().NodeCleanups()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := cleanups.Get(ctx, node.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			created, err := cleanups.Create(ctx, &infrav1alpha1.NodeCleanup{
+				ObjectMeta: metav1.ObjectMeta{Name: node.Name},
+				Spec:       spec,
+			}, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Created concurrently by another worker
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			created.Status = infrav1alpha1.NodeCleanupStatus{Phase: infrav1alpha1.NodeCleanupPending}
+			_, err = cleanups.UpdateStatus(ctx, created, metav1.UpdateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if existing.Spec.NodeUID == node.UID {
+			return nil
+		}
+
+		klog.InfoS("Resetting NodeCleanup recorded for a different node UID", "node", node.Name,
+			"recordedUID", existing.Spec.NodeUID, "uid", node.UID)
+		existing.Spec = spec
+		updated, err := cleanups.Update(ctx, existing, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		updated.Status = infrav1alpha1.NodeCleanupStatus{Phase: infrav1alpha1.NodeCleanupPending}
+		_, err = cleanups.UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to record NodeCleanup", "node", node.Name)
+	}
+}
+
+// prune deletes the records of nodes that are gone once their last activity
+// is older than the retention. nodeUID returns the UID of an existing node;
+// a record of a node that still exists with the recorded UID is kept whatever
+// its phase.
+func (s *statusRecorder) prune(ctx context.Context, nodeUID func(name string) (types.UID, bool)) {
+	cleanups := s.client.InfraV1alpha1().NodeCleanups()
+	list, err := cleanups.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Failed to list NodeCleanups for pruning")
+		return
+	}
+
+	cutoff := time.Now().Add(-s.retention)
+	for i := range list.Items {
+		record := &list.Items[i]
+		if uid, exists := nodeUID(record.Name); exists && uid == record.Spec.NodeUID {
+			continue
+		}
+		if lastActivity(record).After(cutoff) {
+			continue
+		}
+
+		// A record reset for a new node in the meantime has a new
+		// resourceVersion and is kept
+		err := cleanups.Delete(ctx, record.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &record.UID, ResourceVersion: &record.ResourceVersion},
+		})
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			klog.ErrorS(err, "Failed to prune NodeCleanup", "node", record.Name)
+			continue
+		}
+		klog.V(2).InfoS("Pruned NodeCleanup of deleted node", "node", record.Name,
+			"phase", record.Status.Phase, "retention", s.retention)
+	}
+}
+
+// lastActivity returns when a record last changed: its completion, else its
+// last attempt, else the node's deletion
+func lastActivity(record *infrav1alpha1.NodeCleanup) time.Time {
+	switch {
+	case record.Status.CompletionTime != nil:
+		return record.Status.CompletionTime.Time
+	case record.Status.LastAttemptTime != nil:
+		return record.Status.LastAttemptTime.Time
+	default:
+		return record.Spec.DeletionTimestamp.Time
+	}
+}
+
+// attemptStarted marks a new cleanup attempt as running
+func (s *statusRecorder) attemptStarted(ctx context.Context, node *corev1.Node) {
+	s.ensure(ctx, node)
+	s.update(ctx, node.Name, func(status *infrav1alpha1.NodeCleanupStatus) {
+		now := metav1.Now()
+		status.Phase = infrav1alpha1.NodeCleanupRunning
+		status.Attempts++
+		status.LastAttemptTime = &now
+		if status.StartTime == nil {
+			status.StartTime = &now
+		}
+	})
+}
+
+// attemptFailed records the error of a failed attempt
+func (s *statusRecorder) attemptFailed(ctx context.Context, nodeName string, cause error) {
+	s.update(ctx, nodeName, func(status *infrav1alpha1.NodeCleanupStatus) {
+		status.Phase = infrav1alpha1.NodeCleanupFailed
+		status.LastError = cause.Error()
+	})
+}
+
+// succeeded records that the finalizer was removed
+func (s *statusRecorder) succeeded(ctx context.Context, nodeName string) {
+	s.update(ctx, nodeName, func(status *infrav1alpha1.NodeCleanupStatus) {
+		now := metav1.Now()
+		status.Phase = infrav1alpha1.NodeCleanupSucceeded
+		status.CompletionTime = &now
+	})
+}
+
+// skipped records that the skip annotation bypassed the given plugins
+func (s *statusRecorder) skipped(ctx context.Context, node *corev1.Node, pluginNames []string) {
+	s.ensure(ctx, node)
+	s.update(ctx, node.Name, func(status *infrav1alpha1.NodeCleanupStatus) {
+		now := metav1.Now()
+		status.Phase = infrav1alpha1.NodeCleanupSucceeded
+		status.CompletionTime = &now
+		status.LastError = ""
+		for _, name := range pluginNames {
+			setPluginResult(status, name, func(result *infrav1alpha1.PluginResult) {
+				markSkipped(result, fmt.Sprintf("cleanup bypassed by annotation %s", constants.SkipCleanupAnnotation))
+			})
+		}
+	})
+}
+
+// awaitingApproval records that cleanup waits for manual approval
+func (s *statusRecorder) awaitingApproval(ctx context.Context, node *corev1.Node, requiredBy []string) {
+	s.ensure(ctx, node)
+	s.update(ctx, node.Name, func(status *infrav1alpha1.NodeCleanupStatus) {
+		status.Phase = infrav1alpha1.NodeCleanupAwaitingApproval
+		status.ApprovalRequiredBy = requiredBy
+		status.ApprovedBy = ""
+		status.ApprovalTime = nil
+	})
+}
+
+// approved records who approved the cleanup
+func (s *statusRecorder) approved(ctx context.Context, node *corev1.Node, requiredBy []string, approver string) {
+	s.ensure(ctx, node)
+	s.update(ctx, node.Name, func(status *infrav1alpha1.NodeCleanupStatus) {
+		now := metav1.Now()
+		status.ApprovalRequiredBy = requiredBy
+		status.ApprovedBy = approver
+		status.ApprovalTime = &now
+	})
+}
+
+// forceReleased records that the finalizer was removed at the deadline
+// without completing cleanup
+func (s *statusRecorder) forceReleased(ctx context.Context, nodeName string, elapsed time.Duration) {
+	s.update(ctx, nodeName, func(status *infrav1alpha1.NodeCleanupStatus) {
+		now := metav1.Now()
+		status.Phase = infrav1alpha1.NodeCleanupFailed
+		status.CompletionTime = &now
+		status.LastError = fmt.Sprintf("finalizer force-released after %v without completing cleanup", elapsed.Round(time.Second))
+	})
+}
+
+// planned records a dry-run plan. The cleanup itself stays Pending.
+func (s *statusRecorder) planned(ctx context.Context, node *corev1.Node, plan []plugins.PlannedPlugin) {
+	s.ensure(ctx, node)
+	s.update(ctx, node.Name, func(status *infrav1alpha1.NodeCleanupStatus) {
+		for _, entry := range plan {
+			setPluginResult(status, entry.Name, func(result *infrav1alpha1.PluginResult) {
+				if !entry.WouldRun {
+					markSkipped(result, "dry run: "+entry.SkipReason)
+					return
+				}
+				now := metav1.Now()
+				result.Phase = infrav1alpha1.PluginPlanned
+				result.StartTime = nil
+				result.CompletionTime = &now
+				result.Message = planMessage(entry)
+			})
+		}
+	})
+}
+
+// PluginStarted implements plugins.Observer
+func (s *statusRecorder) PluginStarted(ctx context.Context, node *corev1.Node, name string) {
+	s.setPlugin(ctx, node.Name, name, func(result *infrav1alpha1.PluginResult) {
+		now := metav1.Now()
+		result.Phase = infrav1alpha1.PluginRunning
+		result.StartTime = &now
+		result.CompletionTime = nil
+		result.Message = ""
+	})
+}
+
+// PluginSucceeded implements plugins.Observer
+func (s *statusRecorder) PluginSucceeded(ctx context.Context, node *corev1.Node, name string) {
+	s.pluginFinished(ctx, node.Name, name, infrav1alpha1.PluginSucceeded, "")
+}
+
+// PluginFailed implements plugins.Observer
+func (s *statusRecorder) PluginFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	phase := infrav1alpha1.PluginFailed
+	if plugins.IsTimeout(err) {
+		phase = infrav1alpha1.PluginTimedOut
+	}
+	s.pluginFinished(ctx, node.Name, name, phase, err.Error())
+}
+
+// PluginSkipped implements plugins.Observer
+func (s *statusRecorder) PluginSkipped(ctx context.Context, node *corev1.Node, name string, reason string) {
+	s.setPlugin(ctx, node.Name, name, func(result *infrav1alpha1.PluginResult) {
+		markSkipped(result, reason)
+	})
+}
+
+// PluginRolledBack implements plugins.Observer
+func (s *statusRecorder) PluginRolledBack(ctx context.Context, node *corev1.Node, name string) {
+	s.pluginFinished(ctx, node.Name, name, infrav1alpha1.PluginRolledBack, "cleanup rolled back after permanent failure")
+}
+
+// PluginRollbackFailed implements plugins.Observer
+func (s *statusRecorder) PluginRollbackFailed(ctx context.Context, node *corev1.Node, name string, err error) {
+	s.pluginFinished(ctx, node.Name, name, infrav1alpha1.PluginRollbackFailed, err.Error())
+}
+
+func (s *statusRecorder) pluginFinished(ctx context.Context, nodeName, plugin string, phase infrav1alpha1.PluginPhase, message string) {
+	s.setPlugin(ctx, nodeName, plugin, func(result *infrav1alpha1.PluginResult) {
+		now := metav1.Now()
+		result.Phase = phase
+		result.CompletionTime = &now
+		result.Message = message
+	})
+}
+
+// setPlugin updates the result entry of a plugin
+func (s *statusRecorder) setPlugin(ctx context.Context, nodeName, plugin string, mutate func(*infrav1alpha1.PluginResult)) {
+	s.update(ctx, nodeName, func(status *infrav1alpha1.NodeCleanupStatus) {
+		setPluginResult(status, plugin, mutate)
+	})
+}
+
+// update applies mutate to the latest status, retrying on conflicts
+func (s *statusRecorder) update(ctx context.Context, nodeName string, mutate func(*infrav1alpha1.NodeCleanupStatus)) {
+	cleanups := s.client.InfraV1alpha1().NodeCleanups()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cleanup, err := cleanups.Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		mutate(&cleanup.Status)
+		_, err = cleanups.UpdateStatus(ctx, cleanup, metav1.UpdateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		klog.V(2).InfoS("No NodeCleanup to update", "node", nodeName)
+	} else if err != nil {
+		klog.ErrorS(err, "Failed to update NodeCleanup status", "node", nodeName)
+	}
+}
+
+// setPluginResult applies mutate to the plugin's result, appending it on first use
+func setPluginResult(status *infrav1alpha1.NodeCleanupStatus, plugin string, mutate func(*infrav1alpha1.PluginResult)) {
+	for i := range status.Plugins {
+		if status.Plugins[i].Name == plugin {
+			mutate(&status.Plugins[i])
+			return
+		}
+	}
+	result := infrav1alpha1.PluginResult{Name: plugin}
+	mutate(&result)
+	status.Plugins = append(status.Plugins, result)
+}
+
+// markSkipped marks a plugin as skipped. A plugin completed by an earlier
+// attempt keeps its Succeeded result.
+func markSkipped(result *infrav1alpha1.PluginResult, reason string) {
+	if result.Phase == infrav1alpha1.PluginSucceeded {
+		return
+	}
+	now := metav1.Now()
+	result.Phase = infrav1alpha1.PluginSkipped
+	result.CompletionTime = &now
+	result.Message = reason
+}
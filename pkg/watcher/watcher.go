@@ -7,14 +7,25 @@ import (
 	"sync"
 	"time"
 
+	"github.com/894/node-cleanup-webhook/pkg/apis/generated/clientset/versioned"
+	"github.com/894/node-cleanup-webhook/pkg/approval"
+	"github.com/894/node-cleanup-webhook/pkg/audit"
+	"github.com/894/node-cleanup-webhook/pkg/config"
 	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/maintenance"
+	"github.com/894/node-cleanup-webhook/pkg/metrics"
 	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	"github.com/894/node-cleanup-webhook/pkg/scope"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 )
 
@@ -22,25 +33,80 @@ import (
 type Watcher struct {
 	client         kubernetes.Interface
 	informer       cache.SharedIndexInformer
-	workqueue      chan string
+	queue          workqueue.RateLimitingInterface
 	pluginRegistry *plugins.Registry
-	// Track nodes being processed to avoid duplicate work
-	processing sync.Map
+	// Records each node's cleanup lifecycle in a NodeCleanup resource
+	status *statusRecorder
+	// Records Kubernetes Events against the Node
+	recorder record.EventRecorder
+	// Append-only record of every cleanup decision
+	audit *audit.Log
+	// Start time per node and plugin, for the audited plugin run time
+	pluginStarts sync.Map
+	// Nodes that get the finalizer
+	scope *scope.Scope
+	// Number of concurrent cleanup workers
+	workers int
+	// Nodes currently being processed; events for them are dropped so a
+	// failure is retried on the backoff schedule rather than immediately
+	inFlight sync.Map
+	// Nodes whose cleanup exhausted MaxRetryAttempts, keyed by node name.
+	// The value is the RetryCleanupAnnotation seen when the node was parked.
+	exhausted sync.Map
+	// Global cleanup deadline tiers, overridable per node by annotation
+	deadlines deadlines
+	// Highest escalationTier reached per node name
+	escalated sync.Map
+	// Only plan cleanups for every node (see isDryRun)
+	dryRun bool
+	// ResourceVersion whose dry-run plan was recorded, per node name
+	planned sync.Map
+	// Which nodes wait for manual approval before cleanup
+	approval *approval.Policy
+	// Nodes parked awaiting approval; the value is whether the approval
+	// timeout was escalated
+	awaiting sync.Map
+	// Approver announced per node name, "" when cleanup proceeded on timeout
+	approved sync.Map
+	// When cleanup may start (see pkg/maintenance)
+	schedule *maintenance.Schedule
+	// Nodes whose cleanup waits for a maintenance window, with the time
+	// they are requeued
+	deferred sync.Map
+	// Pauses new cleanups after a burst of deletions (see breaker)
+	breaker *breaker
+	// Nodes whose cleanup is held by the tripped circuit breaker
+	paused sync.Map
 	// Context for background operations
 	ctx context.Context
 }
 
 // New creates a new cleanup watcher
-func New(ctx context.Context, client kubernetes.Interface, pluginRegistry *plugins.Registry) *Watcher {
+func New(ctx context.Context, client kubernetes.Interface, cleanupClient versioned.Interface, pluginRegistry *plugins.Registry, recorder record.EventRecorder, nodeScope *scope.Scope, approvalPolicy *approval.Policy, schedule *maintenance.Schedule, auditLog *audit.Log, cfg *config.Config) *Watcher {
 	// Create informer factory
 	factory := informers.NewSharedInformerFactory(client, constants.DefaultInformerResyncPeriod)
 	nodeInformer := factory.Core().V1().Nodes().Informer()
 
+	// Per-node exponential backoff: DefaultRetryDelay, 2x, 4x, ... capped at ExponentialBackoffMax
+	rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(constants.DefaultRetryDelay, constants.ExponentialBackoffMax)
+
 	watcher := &Watcher{
-		client:         client,
-		informer:       nodeInformer,
-		workqueue:      make(chan string, constants.DefaultWorkQueueSize),
+		client:   client,
+		informer: nodeInformer,
+		queue: workqueue.NewRateLimitingQueueWithConfig(rateLimiter, workqueue.RateLimitingQueueConfig{
+			Name: constants.WorkQueueName,
+		}),
 		pluginRegistry: pluginRegistry,
+		status:         newStatusRecorder(cleanupClient, cfg.NodeCleanupRetention),
+		recorder:       recorder,
+		audit:          auditLog,
+		scope:          nodeScope,
+		workers:        cfg.Workers,
+		deadlines:      deadlinesFromConfig(cfg),
+		dryRun:         cfg.DryRun,
+		approval:       approvalPolicy,
+		schedule:       schedule,
+		breaker:        newBreaker(client, cfg),
 		ctx:            ctx,
 	}
 
@@ -50,6 +116,8 @@ func New(ctx context.Context, client kubernetes.Interface, pluginRegistry *plugi
 			node := obj.(*corev1.Node)
 			klog.V(2).InfoS("Node added event", "node", node.Name)
 			watcher.ensureFinalizer(node)
+			// Pick up nodes that were already terminating when the watcher started
+			watcher.enqueueIfDeleting(node)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			node := newObj.(*corev1.Node)
@@ -58,9 +126,20 @@ func New(ctx context.Context, client kubernetes.Interface, pluginRegistry *plugi
 			watcher.enqueueIfDeleting(node)
 		},
 		DeleteFunc: func(obj interface{}) {
-			// Node is already gone, just log
+			// Node is already gone, just log and drop any retry state
 			if node, ok := obj.(*corev1.Node); ok {
 				klog.InfoS("Node deleted from cache", "node", node.Name)
+				if _, planned := watcher.planned.Load(node.Name); !planned {
+					watcher.planIfDeletedInDryRun(node)
+				}
+				watcher.exhausted.Delete(node.Name)
+				watcher.escalated.Delete(node.Name)
+				watcher.planned.Delete(node.Name)
+				watcher.awaiting.Delete(node.Name)
+				watcher.approved.Delete(node.Name)
+				watcher.deferred.Delete(node.Name)
+				watcher.paused.Delete(node.Name)
+				watcher.breaker.forget(node.Name)
 			}
 		},
 	})
@@ -68,15 +147,29 @@ func New(ctx context.Context, client kubernetes.Interface, pluginRegistry *plugi
 	return watcher
 }
 
-// ensureFinalizer adds the finalizer to a node if it doesn't have it
+// ensureFinalizer adds the finalizer to a node in scope that doesn't have it
+// and removes it from a node that fell out of scope
 func (w *Watcher) ensureFinalizer(node *corev1.Node) {
 	// Skip if node is being deleted
 	if node.DeletionTimestamp != nil {
 		return
 	}
 
+	hasFinalizer := containsFinalizer(node.Finalizers, constants.FinalizerName)
+	if reason := w.scope.Reason(node); reason != "" {
+		if hasFinalizer {
+			go w.releaseOutOfScope(w.ctx, node, reason)
+		}
+		return
+	}
+
 	// Skip if finalizer already exists
-	if containsFinalizer(node.Finalizers, constants.FinalizerName) {
+	if hasFinalizer {
+		return
+	}
+
+	if w.isDryRun(node) {
+		klog.V(2).InfoS("Dry run: would add finalizer", "node", node.Name, "finalizer", constants.FinalizerName)
 		return
 	}
 
@@ -86,6 +179,7 @@ func (w *Watcher) ensureFinalizer(node *corev1.Node) {
 			klog.ErrorS(err, "Failed to add finalizer", "node", node.Name, "finalizer", constants.FinalizerName)
 		} else {
 			klog.InfoS("Finalizer added successfully", "node", node.Name, "finalizer", constants.FinalizerName)
+			w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonFinalizerAdded, "Added finalizer %s", constants.FinalizerName)
 		}
 	}()
 }
@@ -96,22 +190,95 @@ func (w *Watcher) enqueueIfDeleting(node *corev1.Node) {
 		return
 	}
 
-	// Only process if our finalizer is present
+	// Only process if our finalizer is present. A node that left the scope
+	// after it started terminating is still cleaned up.
 	if !containsFinalizer(node.Finalizers, constants.FinalizerName) {
+		// Dry run never adds the finalizer, so its nodes are planned from
+		// the deletion itself
+		if w.isDryRun(node) && w.scope.Reason(node) == "" && !w.alreadyPlanned(node) {
+			klog.V(2).InfoS("Dry run: node enqueued for planning", "node", node.Name)
+			w.queue.Add(node.Name)
+		}
 		return
 	}
 
-	// Check if already being processed
-	if _, loaded := w.processing.LoadOrStore(node.Name, true); loaded {
-		klog.V(2).Infof("Node %s already being processed", node.Name)
+	// Count the deletion towards the circuit breaker limits
+	w.breaker.observeDeletion(node, w.nodeCounts)
+
+	// Escalate overdue cleanups. A node due for force-release is handed to a
+	// worker even when held or parked; the queue keeps it from running
+	// alongside a cleanup in flight.
+	if w.checkDeadline(node) {
+		w.queue.Add(node.Name)
 		return
 	}
 
-	klog.InfoS("Node enqueued for cleanup", "node", node.Name, "deletionTimestamp", node.DeletionTimestamp.Time)
-	w.workqueue <- node.Name
+	// A run is in progress or a retry is already scheduled with backoff;
+	// informer resyncs must not short-circuit it
+	if _, running := w.inFlight.Load(node.Name); running {
+		klog.V(3).InfoS("Node already being processed", "node", node.Name)
+		return
+	}
+	if w.queue.NumRequeues(node.Name) > 0 {
+		klog.V(3).InfoS("Node already scheduled for retry", "node", node.Name, "attempts", w.queue.NumRequeues(node.Name))
+		return
+	}
+
+	if w.isExhausted(node) {
+		klog.V(3).InfoS("Node cleanup exhausted retries - waiting for operator action", "node", node.Name,
+			"retryAnnotation", constants.RetryCleanupAnnotation)
+		return
+	}
+
+	if w.isAwaitingApproval(node) {
+		klog.V(3).InfoS("Node cleanup awaiting approval", "node", node.Name,
+			"annotation", constants.CleanupApprovedByAnnotation)
+		return
+	}
+
+	if w.isDeferred(node) {
+		klog.V(3).InfoS("Node cleanup deferred to the next maintenance window", "node", node.Name)
+		return
+	}
+
+	if w.isPaused(node) {
+		klog.V(3).InfoS("Node cleanup paused by the circuit breaker", "node", node.Name)
+		return
+	}
+
+	if w.isDryRun(node) && w.alreadyPlanned(node) {
+		klog.V(3).InfoS("Dry run: cleanup already planned", "node", node.Name)
+		return
+	}
+
+	// The queue de-duplicates, so repeated update events for the same node are harmless
+	klog.V(2).InfoS("Node enqueued for cleanup", "node", node.Name, "deletionTimestamp", node.DeletionTimestamp.Time)
+	w.queue.Add(node.Name)
+
+	// Record the node as pending in the background
+	go w.status.ensure(w.ctx, node)
 }
 
-// Run starts the watcher
+// isExhausted reports whether the node's cleanup gave up after MaxRetryAttempts.
+// Setting the skip annotation or changing the retry annotation re-arms the node.
+func (w *Watcher) isExhausted(node *corev1.Node) bool {
+	value, ok := w.exhausted.Load(node.Name)
+	if !ok {
+		return false
+	}
+
+	if node.Annotations[constants.SkipCleanupAnnotation] == "true" ||
+		node.Annotations[constants.RetryCleanupAnnotation] != value.(string) {
+		klog.InfoS("Operator action detected - re-arming node cleanup", "node", node.Name)
+		w.exhausted.Delete(node.Name)
+		return false
+	}
+
+	return true
+}
+
+// Run starts the watcher and blocks until the context is cancelled and all
+// in-flight cleanups have returned
 func (w *Watcher) Run() {
 	klog.InfoS("Starting cleanup watcher", "finalizerName", constants.FinalizerName)
 
@@ -120,80 +287,262 @@ func (w *Watcher) Run() {
 
 	// Wait for cache sync
 	if !cache.WaitForCacheSync(w.ctx.Done(), w.informer.HasSynced) {
+		if w.ctx.Err() != nil {
+			w.queue.ShutDown()
+			return
+		}
 		klog.Fatal("Failed to sync informer cache")
 	}
 	klog.InfoS("Informer cache synced successfully")
 
+	// Report node gauges from the informer cache while this replica runs the watcher
+	metrics.SetNodeCounts(w.nodeCounts)
+	defer metrics.SetNodeCounts(nil)
+
+	// Load the circuit breaker state left by a previous leader, then watch
+	// for resets and manual trips, which apply even without limits
+	if _, err := w.breaker.sync(w.ctx); err != nil {
+		klog.ErrorS(err, "Failed to load circuit breaker state")
+	}
+	go w.breaker.run(w.ctx, w.resumePaused)
+
+	// Delete the NodeCleanup records of nodes that are long gone
+	if w.status.retention > 0 {
+		go wait.UntilWithContext(w.ctx, func(ctx context.Context) {
+			w.status.prune(ctx, w.nodeUID)
+		}, constants.NodeCleanupPruneInterval)
+	}
+
 	// Initialize finalizers on existing nodes
 	if err := w.initializeExistingNodes(w.ctx); err != nil {
 		klog.ErrorS(err, "Failed to initialize existing nodes")
 	}
 
-	// Process work queue
-	for {
-		select {
-		case nodeName := <-w.workqueue:
-			w.processNode(w.ctx, nodeName)
-		case <-w.ctx.Done():
-			klog.InfoS("Cleanup watcher stopping gracefully")
-			return
-		}
+	// Process work queue with a pool of workers. The queue never hands the
+	// same node to two workers at once: an item re-added while it is being
+	// processed is held back until the current worker calls Done.
+	klog.InfoS("Starting cleanup workers", "count", w.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(w.runWorker, time.Second, w.ctx.Done())
+		}()
+	}
+
+	<-w.ctx.Done()
+	klog.InfoS("Cleanup watcher stopping gracefully - waiting for in-flight cleanups")
+	w.queue.ShutDown()
+	wg.Wait()
+	klog.InfoS("Cleanup watcher stopped")
+}
+
+// HasSynced reports whether the node informer cache has synced
+func (w *Watcher) HasSynced() bool {
+	return w.informer.HasSynced()
+}
+
+// nodeUID returns the UID of a node in the informer cache
+func (w *Watcher) nodeUID(name string) (types.UID, bool) {
+	obj, exists, err := w.informer.GetStore().GetByKey(name)
+	if err != nil || !exists {
+		return "", false
+	}
+	return obj.(*corev1.Node).UID, true
+}
+
+// runWorker processes items until the queue is shut down
+func (w *Watcher) runWorker() {
+	for w.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem takes one node off the queue and handles the outcome
+func (w *Watcher) processNextWorkItem() bool {
+	item, shutdown := w.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer w.queue.Done(item)
+
+	// Leave queued nodes for the next leader once we are shutting down
+	if w.ctx.Err() != nil {
+		return false
+	}
+
+	nodeName := item.(string)
+	w.inFlight.Store(nodeName, true)
+	defer w.inFlight.Delete(nodeName)
+
+	err := w.processNode(w.ctx, nodeName)
+	w.handleResult(nodeName, err)
+	return true
+}
+
+// handleResult forgets successful nodes, retries failures with exponential
+// backoff and parks nodes that failed MaxRetryAttempts times
+func (w *Watcher) handleResult(nodeName string, err error) {
+	if w.ctx.Err() != nil {
+		klog.V(2).InfoS("Cleanup interrupted by shutdown", "node", nodeName)
+		return
+	}
+
+	if err == nil {
+		w.queue.Forget(nodeName)
+		return
+	}
+	w.status.attemptFailed(w.ctx, nodeName, err)
+
+	// Timeouts are retried like failures but reported separately
+	outcome := "failed"
+	if plugins.IsTimeout(err) {
+		outcome = "timed out"
+	}
+
+	attempt := w.queue.NumRequeues(nodeName) + 1
+	if attempt < constants.MaxRetryAttempts {
+		klog.ErrorS(err, "Cleanup "+outcome+" - will retry", "node", nodeName,
+			"attempt", attempt, "maxAttempts", constants.MaxRetryAttempts,
+			"retryDelay", retryDelay(attempt), "timedOut", plugins.IsTimeout(err))
+		w.recorder.Eventf(nodeRef(nodeName), corev1.EventTypeWarning, constants.ReasonCleanupRetryScheduled,
+			"Cleanup attempt %d/%d %s, retrying in %v: %v", attempt, constants.MaxRetryAttempts, outcome, retryDelay(attempt), err)
+		w.queue.AddRateLimited(nodeName)
+		metrics.CleanupAttemptsTotal.WithLabelValues(metrics.ResultRetry).Inc()
+		w.audit.Record(audit.Record{Event: audit.EventCleanupFailed, Node: nodeName, Outcome: audit.OutcomeRetry,
+			Attempt: attempt, Error: err.Error()})
+		return
 	}
+	metrics.CleanupAttemptsTotal.WithLabelValues(metrics.ResultExhausted).Inc()
+	w.audit.Record(audit.Record{Event: audit.EventCleanupFailed, Node: nodeName, Outcome: audit.OutcomeExhausted,
+		Attempt: attempt, Error: err.Error()})
+
+	// Retries exhausted: stop retrying and leave the finalizer in place until
+	// an operator sets the skip annotation or changes the retry annotation
+	w.queue.Forget(nodeName)
+	retryValue := ""
+	node, getErr := w.client.CoreV1().Nodes().Get(w.ctx, nodeName, metav1.GetOptions{})
+	if getErr == nil {
+		retryValue = node.Annotations[constants.RetryCleanupAnnotation]
+	}
+	w.exhausted.Store(nodeName, retryValue)
+
+	klog.ErrorS(err, "Cleanup failed permanently - giving up until operator action",
+		"node", nodeName,
+		"timedOut", plugins.IsTimeout(err),
+		"attempts", attempt,
+		"maxAttempts", constants.MaxRetryAttempts,
+		"retryAnnotation", constants.RetryCleanupAnnotation,
+		"skipAnnotation", constants.SkipCleanupAnnotation)
+	w.recorder.Eventf(nodeRef(nodeName), corev1.EventTypeWarning, constants.ReasonCleanupFailed,
+		"Cleanup failed after %d attempts, finalizer kept until %s or %s is set: %v",
+		attempt, constants.RetryCleanupAnnotation, constants.SkipCleanupAnnotation, err)
+
+	if getErr != nil {
+		klog.ErrorS(getErr, "Cannot roll back completed plugins", "node", nodeName)
+		return
+	}
+	w.rollback(node, err)
 }
 
-func (w *Watcher) processNode(ctx context.Context, nodeName string) {
-	defer w.processing.Delete(nodeName)
+// rollback undoes the plugins that completed before the node's cleanup failed
+// permanently. Rolled back plugins are removed from the checkpoint, so they run
+// again when an operator re-arms the cleanup.
+func (w *Watcher) rollback(node *corev1.Node, cause error) {
+	checkpoint := newNodeCheckpoint(w.client, node)
+	if len(checkpoint.CompletedPlugins()) == 0 {
+		return
+	}
 
+	if err := w.pluginRegistry.RollbackAll(w.ctx, node, checkpoint, cause, w.observer()); err != nil {
+		klog.ErrorS(err, "Rollback of completed plugins incomplete", "node", node.Name)
+	}
+}
+
+// processNode runs cleanup for a single node. A returned error means the node
+// should be retried.
+func (w *Watcher) processNode(ctx context.Context, nodeName string) error {
 	klog.InfoS("Processing node cleanup", "node", nodeName)
 
 	// Get current node state
 	node, err := w.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
-		klog.ErrorS(err, "Failed to get node", "node", nodeName)
-		return
+		if apierrors.IsNotFound(err) {
+			klog.V(2).InfoS("Node no longer exists", "node", nodeName)
+			return nil
+		}
+		return fmt.Errorf("failed to get node: %w", err)
 	}
 
 	// Double-check it's still being deleted with our finalizer
-	if node.DeletionTimestamp == nil || !containsFinalizer(node.Finalizers, constants.FinalizerName) {
+	hasFinalizer := containsFinalizer(node.Finalizers, constants.FinalizerName)
+	if node.DeletionTimestamp == nil || (!hasFinalizer && !w.isDryRun(node)) {
 		klog.V(2).InfoS("Node no longer needs cleanup", "node", nodeName,
-			"isDeleting", node.DeletionTimestamp != nil,
-			"hasFinalizer", containsFinalizer(node.Finalizers, constants.FinalizerName))
-		return
+			"isDeleting", node.DeletionTimestamp != nil, "hasFinalizer", hasFinalizer)
+		return nil
 	}
 
-	// Check for skip annotation
+	// A dry-run node without the finalizer is not held, so there is nothing
+	// to release; only the plan is recorded
+	if !hasFinalizer {
+		return w.planCleanup(ctx, node)
+	}
+
+	// Past its force-release deadline the node is released without cleanup.
+	// The deadline bounds how long a node can stay Terminating, so it
+	// overrides the approval, maintenance window and circuit breaker holds
+	// below; releasing skips the destructive cleanup those holds guard.
+	if elapsed, due := w.forceReleaseDue(node); due {
+		w.forceRelease(ctx, node, elapsed)
+		return nil
+	}
+
+	// Check for skip annotation; it also releases a dry-run node
 	if node.Annotations[constants.SkipCleanupAnnotation] == "true" {
 		klog.InfoS("Skip cleanup annotation detected - bypassing cleanup",
 			"node", nodeName,
 			"annotation", constants.SkipCleanupAnnotation)
-		if err := w.removeFinalizer(ctx, node); err != nil {
-			klog.ErrorS(err, "Failed to remove finalizer after skip", "node", nodeName)
+		w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonCleanupSkipped,
+			"Cleanup bypassed by annotation %s", constants.SkipCleanupAnnotation)
+		w.audit.Record(audit.Record{Event: audit.EventCleanupSkipped, Node: nodeName, NodeUID: string(node.UID),
+			Reason: "annotation " + constants.SkipCleanupAnnotation})
+		if err := w.removeFinalizer(ctx, node, "cleanup skipped"); err != nil {
+			return fmt.Errorf("failed to remove finalizer after skip: %w", err)
 		}
-		return
+		w.status.skipped(ctx, node, w.pluginRegistry.GetEnabledPlugins())
+		metrics.CleanupAttemptsTotal.WithLabelValues(metrics.ResultSkipped).Inc()
+		return nil
 	}
 
-	// Run cleanup
-	cleanupErr := w.runCleanup(ctx, node)
-	if cleanupErr != nil {
-		klog.ErrorS(cleanupErr, "Cleanup failed - will retry", "node", nodeName, "retryDelay", "10s")
+	// Dry run: record the plan and leave the node held by the finalizer. The
+	// skip annotation and the force-release deadline above still release it.
+	if w.isDryRun(node) {
+		return w.planCleanup(ctx, node)
+	}
 
-		// Re-enqueue for retry after backoff (respects context cancellation)
-		go func() {
-			select {
-			case <-time.After(constants.DefaultRetryDelay):
-				w.processing.Delete(nodeName)
-				// Re-fetch and re-enqueue
-				if n, err := w.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{}); err == nil {
-					w.enqueueIfDeleting(n)
-				}
-			case <-ctx.Done():
-				// Context cancelled, stop retry
-				klog.V(2).InfoS("Retry cancelled due to context cancellation", "node", nodeName)
-				w.processing.Delete(nodeName)
-				return
-			}
-		}()
-		return
+	// Destructive cleanup may need a human to approve it first
+	if w.awaitApproval(ctx, node) {
+		return nil
+	}
+
+	// Destructive cleanup only starts inside a maintenance window
+	if w.deferToWindow(node) {
+		return nil
+	}
+
+	// A burst of deletions trips the circuit breaker and holds new cleanups
+	if w.pauseForBreaker(node) {
+		return nil
+	}
+
+	// Run cleanup
+	attempt := w.queue.NumRequeues(nodeName) + 1
+	w.status.attemptStarted(ctx, node)
+	w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonCleanupStarted,
+		"Cleanup attempt %d/%d started", attempt, constants.MaxRetryAttempts)
+	w.audit.Record(audit.Record{Event: audit.EventCleanupStarted, Node: nodeName, NodeUID: string(node.UID), Attempt: attempt})
+	if err := w.runCleanup(ctx, node); err != nil {
+		return err
 	}
 
 	// Cleanup succeeded - remove finalizer
@@ -202,23 +551,25 @@ func (w *Watcher) processNode(ctx context.Context, nodeName string) {
 	// Re-fetch node to get latest version
 	node, err = w.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
-		klog.ErrorS(err, "Failed to get node for finalizer removal", "node", nodeName)
-		return
+		return fmt.Errorf("failed to get node for finalizer removal: %w", err)
 	}
 
-	if err := w.removeFinalizer(ctx, node); err != nil {
-		klog.ErrorS(err, "Failed to remove finalizer", "node", nodeName)
-		return
+	if err := w.removeFinalizer(ctx, node, "cleanup completed"); err != nil {
+		return err
 	}
 
+	w.status.succeeded(ctx, nodeName)
+	w.audit.Record(audit.Record{Event: audit.EventCleanupSucceeded, Node: nodeName, NodeUID: string(node.UID), Attempt: attempt})
+	metrics.CleanupAttemptsTotal.WithLabelValues(metrics.ResultSuccess).Inc()
 	klog.InfoS("Node cleanup completed successfully", "node", nodeName, "finalizer", "removed")
+	return nil
 }
 
 func (w *Watcher) runCleanup(ctx context.Context, node *corev1.Node) error {
 	klog.InfoS("Running cleanup plugins", "node", node.Name)
 
-	// Run all enabled plugins in order
-	if err := w.pluginRegistry.RunAll(ctx, node); err != nil {
+	// Run all enabled plugins in order, resuming after the last completed one
+	if err := w.pluginRegistry.RunAll(ctx, node, newNodeCheckpoint(w.client, node), w.observer()); err != nil {
 		klog.ErrorS(err, "Plugin execution failed", "node", node.Name)
 		return fmt.Errorf("plugin execution failed: %w", err)
 	}
@@ -227,7 +578,13 @@ func (w *Watcher) runCleanup(ctx context.Context, node *corev1.Node) error {
 	return nil
 }
 
-func (w *Watcher) removeFinalizer(ctx context.Context, node *corev1.Node) error {
+// observer reports plugin outcomes to the NodeCleanup status and as events
+func (w *Watcher) observer() plugins.Observer {
+	return plugins.Observers{w.status, eventObserver{recorder: w.recorder}, auditObserver{w}}
+}
+
+// removeFinalizer removes the cleanup finalizer; reason is audited
+func (w *Watcher) removeFinalizer(ctx context.Context, node *corev1.Node, reason string) error {
 	// Build new finalizers list without our finalizer
 	newFinalizers := []string{}
 	for _, f := range node.Finalizers {
@@ -260,10 +617,27 @@ func (w *Watcher) removeFinalizer(ctx context.Context, node *corev1.Node) error
 	}
 
 	klog.InfoS("Finalizer removed successfully", "node", node.Name, "finalizer", constants.FinalizerName)
+	w.audit.Record(audit.Record{Event: audit.EventFinalizerRemoved, Node: node.Name, NodeUID: string(node.UID), Reason: reason})
+	w.recorder.Eventf(node, corev1.EventTypeNormal, constants.ReasonFinalizerRemoved, "Removed finalizer %s", constants.FinalizerName)
 	return nil
 }
 
-// initializeExistingNodes adds finalizers to all existing nodes that don't have them
+// releaseOutOfScope removes the finalizer from a node that no longer matches
+// the node scope
+func (w *Watcher) releaseOutOfScope(ctx context.Context, node *corev1.Node, reason string) {
+	if w.isDryRun(node) {
+		klog.V(2).InfoS("Dry run: would remove finalizer from node out of scope", "node", node.Name, "reason", reason)
+		return
+	}
+
+	klog.InfoS("Node out of scope - removing finalizer", "node", node.Name, "reason", reason)
+	if err := w.removeFinalizer(ctx, node, "out of scope: "+reason); err != nil {
+		klog.ErrorS(err, "Failed to remove finalizer from node out of scope", "node", node.Name)
+	}
+}
+
+// initializeExistingNodes adds finalizers to all existing nodes in scope that
+// don't have them and removes them from nodes out of scope
 func (w *Watcher) initializeExistingNodes(ctx context.Context) error {
 	klog.InfoS("Initializing finalizers on existing nodes", "finalizer", constants.FinalizerName)
 
@@ -286,6 +660,15 @@ func (w *Watcher) initializeExistingNodes(ctx context.Context) error {
 			continue
 		}
 
+		if reason := w.scope.Reason(&node); reason != "" {
+			klog.V(2).InfoS("Skipping node - out of scope", "node", node.Name, "reason", reason)
+			if containsFinalizer(node.Finalizers, constants.FinalizerName) {
+				w.releaseOutOfScope(ctx, &node, reason)
+			}
+			skippedCount++
+			continue
+		}
+
 		// Check if finalizer already exists
 		if containsFinalizer(node.Finalizers, constants.FinalizerName) {
 			klog.V(2).InfoS("Skipping node - already has finalizer", "node", node.Name)
@@ -293,6 +676,12 @@ func (w *Watcher) initializeExistingNodes(ctx context.Context) error {
 			continue
 		}
 
+		if w.isDryRun(&node) {
+			klog.V(2).InfoS("Dry run: would add finalizer to existing node", "node", node.Name)
+			skippedCount++
+			continue
+		}
+
 		// Add finalizer
 		if err := w.addFinalizer(ctx, &node); err != nil {
 			klog.ErrorS(err, "Failed to add finalizer to existing node", "node", node.Name)
@@ -300,6 +689,7 @@ func (w *Watcher) initializeExistingNodes(ctx context.Context) error {
 		}
 
 		klog.InfoS("Finalizer added to existing node", "node", node.Name)
+		w.recorder.Eventf(&node, corev1.EventTypeNormal, constants.ReasonFinalizerAdded, "Added finalizer %s", constants.FinalizerName)
 		addedCount++
 	}
 
@@ -336,9 +726,35 @@ func (w *Watcher) addFinalizer(ctx context.Context, node *corev1.Node) error {
 		return fmt.Errorf("failed to patch node: %w", err)
 	}
 
+	w.audit.Record(audit.Record{Event: audit.EventFinalizerAdded, Node: node.Name, NodeUID: string(node.UID)})
 	return nil
 }
 
+// nodeCounts counts cached nodes carrying the finalizer and, of those, the
+// terminating nodes it is holding
+func (w *Watcher) nodeCounts() (withFinalizer, held int) {
+	for _, obj := range w.informer.GetStore().List() {
+		node, ok := obj.(*corev1.Node)
+		if !ok || !containsFinalizer(node.Finalizers, constants.FinalizerName) {
+			continue
+		}
+		withFinalizer++
+		if node.DeletionTimestamp != nil {
+			held++
+		}
+	}
+	return withFinalizer, held
+}
+
+// retryDelay mirrors the queue's exponential rate limiter for logging
+func retryDelay(attempt int) time.Duration {
+	delay := constants.DefaultRetryDelay << (attempt - 1)
+	if delay <= 0 || delay > constants.ExponentialBackoffMax {
+		return constants.ExponentialBackoffMax
+	}
+	return delay
+}
+
 func containsFinalizer(finalizers []string, target string) bool {
 	for _, f := range finalizers {
 		if f == target {
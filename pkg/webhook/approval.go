@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// validateApproval makes the approval annotation name the user who set it,
+// so an approval cannot be recorded on someone else's behalf. The controller
+// sets it for users approving through its API. It returns nil when the
+// request may go on to the other checks.
+func (s *Server) validateApproval(req *admissionv1.AdmissionRequest, oldNode, node *corev1.Node) *admissionv1.AdmissionResponse {
+	approver := strings.TrimSpace(node.Annotations[constants.CleanupApprovedByAnnotation])
+	if approver == "" || approver == strings.TrimSpace(oldNode.Annotations[constants.CleanupApprovedByAnnotation]) {
+		return nil
+	}
+
+	if approver == req.UserInfo.Username || req.UserInfo.Username == s.controllerUser {
+		klog.InfoS("Cleanup approval recorded", "node", node.Name, "approver", approver, "user", req.UserInfo.Username)
+		return nil
+	}
+
+	klog.InfoS("Denying cleanup approval on behalf of another user", "node", node.Name,
+		"approver", approver, "user", req.UserInfo.Username)
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Code:   http.StatusForbidden,
+			Reason: metav1.StatusReasonForbidden,
+			Message: fmt.Sprintf("annotation %s must name the approving user: set it to %q, not %q",
+				constants.CleanupApprovedByAnnotation, req.UserInfo.Username, approver),
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// validateUpdate denies removal of the cleanup finalizer by anyone but the
+// controller, which would let the node be deleted without cleanup. Other
+// users must set the override annotation with a reason. Cleanup approvals
+// must name the approving user (see validateApproval).
+func (s *Server) validateUpdate(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	var oldNode, node corev1.Node
+	if err := json.Unmarshal(req.OldObject.Raw, &oldNode); err != nil {
+		klog.Errorf("Failed to unmarshal old node: %v", err)
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("failed to unmarshal old node: %v", err),
+			},
+		}
+	}
+	if err := json.Unmarshal(req.Object.Raw, &node); err != nil {
+		klog.Errorf("Failed to unmarshal node: %v", err)
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("failed to unmarshal node: %v", err),
+			},
+		}
+	}
+
+	if response := s.validateApproval(req, &oldNode, &node); response != nil {
+		return response
+	}
+
+	if !hasFinalizer(&oldNode) || hasFinalizer(&node) {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if req.UserInfo.Username == s.controllerUser {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	// The controller releases nodes that left the scope anyway
+	if reason := s.nodeScope.Reason(&node); reason != "" {
+		klog.V(2).InfoS("Node out of scope - allowing finalizer removal", "node", node.Name,
+			"user", req.UserInfo.Username, "reason", reason)
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if reason := strings.TrimSpace(node.Annotations[constants.AllowFinalizerRemovalAnnotation]); reason != "" {
+		klog.InfoS("Finalizer removal override - allowing removal",
+			"node", node.Name,
+			"user", req.UserInfo.Username,
+			"annotation", constants.AllowFinalizerRemovalAnnotation,
+			"reason", reason)
+		return &admissionv1.AdmissionResponse{
+			Allowed: true,
+			Warnings: []string{fmt.Sprintf("finalizer %s removed without cleanup (%s=%q)",
+				constants.FinalizerName, constants.AllowFinalizerRemovalAnnotation, reason)},
+		}
+	}
+
+	klog.InfoS("Denying finalizer removal", "node", node.Name, "user", req.UserInfo.Username)
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Code:   http.StatusForbidden,
+			Reason: metav1.StatusReasonForbidden,
+			Message: fmt.Sprintf("finalizer %s on node %s is removed by the node cleanup controller once cleanup completes; "+
+				"to remove it anyway set annotation %s=<reason> (set %s=true to skip cleanup instead)",
+				constants.FinalizerName, node.Name, constants.AllowFinalizerRemovalAnnotation, constants.SkipCleanupAnnotation),
+		},
+	}
+}
+
+// hasFinalizer reports whether the node carries the cleanup finalizer
+func hasFinalizer(node *corev1.Node) bool {
+	for _, f := range node.Finalizers {
+		if f == constants.FinalizerName {
+			return true
+		}
+	}
+	return false
+}
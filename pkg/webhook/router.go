@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/894/node-cleanup-webhook/pkg/metrics"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// AdmitFunc decides on a single admission request
+type AdmitFunc func(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+// Router serves admission reviews on registered paths. It accepts
+// admission.k8s.io/v1 and v1beta1 reviews and answers in the version it
+// was sent; the two share their wire format, so handlers only see v1 types.
+type Router struct {
+	mux          *http.ServeMux
+	maxBodyBytes int64
+}
+
+// NewRouter creates a Router registering its handlers on mux. Request
+// bodies larger than maxBodyBytes are rejected.
+func NewRouter(mux *http.ServeMux, maxBodyBytes int64) *Router {
+	return &Router{mux: mux, maxBodyBytes: maxBodyBytes}
+}
+
+// Handle registers admit on path. If admit panics the request is answered
+// according to failurePolicy, mirroring the webhook configuration: Ignore
+// allows it, Fail denies it.
+func (rt *Router) Handle(path string, admit AdmitFunc, failurePolicy admissionregistrationv1.FailurePolicyType) {
+	rt.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		klog.V(2).InfoS("Received admission request", "path", path)
+		rt.serve(w, r, path, admit, failurePolicy)
+	})
+}
+
+// serve decodes the AdmissionReview, runs admit and writes the response
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request, path string, admit AdmitFunc, failurePolicy admissionregistrationv1.FailurePolicyType) {
+	start := time.Now()
+	operation, result := "unknown", resultError
+	defer func() { metrics.ObserveWebhook(operation, result, start) }()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "admission reviews must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Read request body
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rt.maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			klog.ErrorS(err, "Admission request too large", "path", path, "limit", rt.maxBodyBytes)
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		klog.Errorf("Failed to read request body: %v", err)
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	// Parse AdmissionReview
+	var admissionReview admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &admissionReview); err != nil {
+		klog.Errorf("Failed to unmarshal admission review: %v", err)
+		http.Error(w, "failed to unmarshal request", http.StatusBadRequest)
+		return
+	}
+	if err := checkReview(&admissionReview); err != nil {
+		klog.ErrorS(err, "Invalid admission review", "path", path)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Process the request
+	request := admissionReview.Request
+	operation = string(request.Operation)
+	response, panicked := rt.admit(r.Context(), path, request, admit, failurePolicy)
+
+	// Build response in the version of the request
+	response.UID = request.UID
+	respBytes, err := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: admissionReview.TypeMeta,
+		Response: response,
+	})
+	if err != nil {
+		klog.Errorf("Failed to marshal response: %v", err)
+		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respBytes)
+	if !panicked {
+		result = admissionResult(response)
+	}
+}
+
+// admit runs the handler, turning a panic into the failure policy's answer
+func (rt *Router) admit(ctx context.Context, path string, req *admissionv1.AdmissionRequest, admit AdmitFunc, failurePolicy admissionregistrationv1.FailurePolicyType) (response *admissionv1.AdmissionResponse, panicked bool) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		klog.ErrorS(fmt.Errorf("%v", recovered), "Admission handler panicked",
+			"path", path, "uid", req.UID, "operation", req.Operation, "name", req.Name,
+			"failurePolicy", failurePolicy, "stack", string(debug.Stack()))
+		metrics.WebhookPanicsTotal.WithLabelValues(path).Inc()
+		response, panicked = failureResponse(failurePolicy), true
+	}()
+
+	response = admit(ctx, req)
+	if response == nil {
+		panic("admission handler returned no response")
+	}
+	return response, false
+}
+
+// failureResponse answers a request whose handler failed
+func failureResponse(failurePolicy admissionregistrationv1.FailurePolicyType) *admissionv1.AdmissionResponse {
+	if failurePolicy == admissionregistrationv1.Fail {
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Code:    http.StatusInternalServerError,
+				Reason:  metav1.StatusReasonInternalError,
+				Message: "internal error in node cleanup webhook",
+			},
+		}
+	}
+	return &admissionv1.AdmissionResponse{
+		Allowed:  true,
+		Warnings: []string{"node cleanup webhook failed internally; request admitted unchecked"},
+	}
+}
+
+// checkReview verifies the review's version and that it carries a request
+func checkReview(review *admissionv1.AdmissionReview) error {
+	gvk := review.GroupVersionKind()
+	switch gvk.GroupVersion() {
+	case admissionv1.SchemeGroupVersion, admissionv1beta1.SchemeGroupVersion:
+	default:
+		return fmt.Errorf("unsupported admission review version %q", review.APIVersion)
+	}
+	if gvk.Kind != "AdmissionReview" {
+		return fmt.Errorf("unexpected kind %q, expected AdmissionReview", gvk.Kind)
+	}
+	if review.Request == nil {
+		return fmt.Errorf("admission review has no request")
+	}
+	return nil
+}
+
+// Admission result label values
+const (
+	resultPatched = "patched"
+	resultAllowed = "allowed"
+	resultDenied  = "denied"
+	resultError   = "error"
+)
+
+// admissionResult classifies a response for the request metrics
+func admissionResult(response *admissionv1.AdmissionResponse) string {
+	switch {
+	case !response.Allowed:
+		return resultDenied
+	case len(response.Patch) > 0:
+		return resultPatched
+	default:
+		return resultAllowed
+	}
+}
@@ -1,12 +1,14 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 
+	"github.com/894/node-cleanup-webhook/pkg/audit"
 	"github.com/894/node-cleanup-webhook/pkg/constants"
+	"github.com/894/node-cleanup-webhook/pkg/plugins"
+	"github.com/894/node-cleanup-webhook/pkg/scope"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,52 +16,35 @@ import (
 )
 
 // Server handles admission webhook requests
-type Server struct{}
-
-// NewServer creates a new webhook server
-func NewServer() *Server {
-	return &Server{}
+type Server struct {
+	pluginRegistry *plugins.Registry
+	nodeScope      *scope.Scope
+	// Username of the controller's service account, the only user allowed
+	// to remove the cleanup finalizer without an override
+	controllerUser string
+	// Records finalizers added at admission
+	audit *audit.Log
+	// Only plan cleanups for every node; see isDryRun
+	dryRun bool
 }
 
-// HandleMutateNode handles the /mutate-node webhook endpoint
-func (s *Server) HandleMutateNode(w http.ResponseWriter, r *http.Request) {
-	klog.V(2).Info("Received mutate request")
-
-	// Read request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		klog.Errorf("Failed to read request body: %v", err)
-		http.Error(w, "failed to read request", http.StatusBadRequest)
-		return
-	}
-
-	// Parse AdmissionReview
-	var admissionReview admissionv1.AdmissionReview
-	if err := json.Unmarshal(body, &admissionReview); err != nil {
-		klog.Errorf("Failed to unmarshal admission review: %v", err)
-		http.Error(w, "failed to unmarshal request", http.StatusBadRequest)
-		return
-	}
-
-	// Process the request
-	response := s.mutateNode(admissionReview.Request)
-
-	// Build response
-	admissionReview.Response = response
-	admissionReview.Response.UID = admissionReview.Request.UID
-
-	respBytes, err := json.Marshal(admissionReview)
-	if err != nil {
-		klog.Errorf("Failed to marshal response: %v", err)
-		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
-		return
-	}
+// NewServer creates a new webhook server. The plugin registry is consulted
+// for pre-flight checks on node deletion; nodes outside nodeScope are
+// neither given the finalizer nor validated. In dry-run mode no finalizer
+// is added, matching the watcher.
+func NewServer(pluginRegistry *plugins.Registry, nodeScope *scope.Scope, controllerUser string, auditLog *audit.Log, dryRun bool) *Server {
+	return &Server{pluginRegistry: pluginRegistry, nodeScope: nodeScope, controllerUser: controllerUser, audit: auditLog, dryRun: dryRun}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(respBytes)
+// isDryRun reports whether the node's cleanup must only be planned, either
+// globally or through the node's dry-run annotation. It must agree with the
+// watcher, which never removes a finalizer from a dry-run node.
+func (s *Server) isDryRun(node *corev1.Node) bool {
+	return s.dryRun || node.Annotations[constants.DryRunAnnotation] == "true"
 }
 
-func (s *Server) mutateNode(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+// MutateNode adds the cleanup finalizer to nodes created in scope
+func (s *Server) MutateNode(_ context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
 	// Only handle CREATE operations
 	if req.Operation != admissionv1.Create {
 		klog.V(2).Infof("Skipping non-CREATE operation: %s", req.Operation)
@@ -78,6 +63,16 @@ func (s *Server) mutateNode(req *admissionv1.AdmissionRequest) *admissionv1.Admi
 		}
 	}
 
+	if reason := s.nodeScope.Reason(&node); reason != "" {
+		klog.V(2).InfoS("Node out of scope - not adding finalizer", "node", node.Name, "reason", reason)
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if s.isDryRun(&node) {
+		klog.V(2).InfoS("Dry run: would add finalizer", "node", node.Name, "finalizer", constants.FinalizerName)
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
 	klog.Infof("Adding finalizer to node %s", node.Name)
 
 	// Check if finalizer already exists
@@ -119,6 +114,10 @@ func (s *Server) mutateNode(req *admissionv1.AdmissionRequest) *admissionv1.Admi
 	}
 
 	klog.V(2).Infof("Patch for node %s: %s", node.Name, string(patchBytes))
+	if req.DryRun == nil || !*req.DryRun {
+		s.audit.Record(audit.Record{Event: audit.EventFinalizerAdded, Node: node.Name, Actor: req.UserInfo.Username,
+			Reason: "node created"})
+	}
 
 	patchType := admissionv1.PatchTypeJSONPatch
 	return &admissionv1.AdmissionResponse{
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/894/node-cleanup-webhook/pkg/constants"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// ValidateNode runs the plugins' pre-flight checks before a node is deleted
+// and guards the cleanup finalizer on updates
+func (s *Server) ValidateNode(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	switch req.Operation {
+	case admissionv1.Delete:
+		return s.validateDelete(ctx, req)
+	case admissionv1.Update:
+		return s.validateUpdate(req)
+	default:
+		klog.V(2).Infof("Skipping operation: %s", req.Operation)
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+}
+
+// validateDelete denies the deletion of a node a plugin objects to
+func (s *Server) validateDelete(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	// The node being deleted is sent as the old object
+	var node corev1.Node
+	if err := json.Unmarshal(req.OldObject.Raw, &node); err != nil {
+		klog.Errorf("Failed to unmarshal node: %v", err)
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("failed to unmarshal node: %v", err),
+			},
+		}
+	}
+
+	// A node already terminating has passed validation once
+	if node.DeletionTimestamp != nil {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if reason := s.nodeScope.Reason(&node); reason != "" {
+		klog.V(2).InfoS("Node out of scope - skipping pre-flight checks", "node", node.Name, "reason", reason)
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if node.Annotations[constants.SkipDeleteValidationAnnotation] == "true" {
+		klog.InfoS("Skip delete validation annotation detected - allowing deletion",
+			"node", node.Name,
+			"annotation", constants.SkipDeleteValidationAnnotation,
+			"user", req.UserInfo.Username)
+		return &admissionv1.AdmissionResponse{
+			Allowed:  true,
+			Warnings: []string{fmt.Sprintf("pre-flight checks bypassed by annotation %s", constants.SkipDeleteValidationAnnotation)},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.ValidationTimeout)
+	defer cancel()
+
+	if err := s.pluginRegistry.ValidateAll(ctx, &node); err != nil {
+		klog.InfoS("Denying node deletion", "node", node.Name, "user", req.UserInfo.Username, "reason", err.Error())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Code:    http.StatusForbidden,
+				Reason:  metav1.StatusReasonForbidden,
+				Message: fmt.Sprintf("node %s cannot be deleted safely: %v (set annotation %s=true to override)", node.Name, err, constants.SkipDeleteValidationAnnotation),
+			},
+		}
+	}
+
+	klog.V(2).InfoS("Node deletion passed pre-flight checks", "node", node.Name)
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}